@@ -35,10 +35,37 @@ type Common struct {
 type Optimization struct {
 	Objective string
 	Asymetric bool
+	Weights   Weights
+	Soft      Soft
 	VNS       VNS
 	SA        SA
 }
 
+// Soft are the default costs of the soft objective per time unit of the
+// service before the ready time and after the due date of a node, steps raise
+// the lateness cost of longer delays
+type Soft struct {
+	Earliness int
+	Lateness  int
+	Steps     []Step
+}
+
+// Step is the lateness cost per time unit of the delay beyond After
+type Step struct {
+	After    int
+	Lateness int
+}
+
+// Weights of the objective of instances with several vehicles, the cost of a
+// plan is the weighted sum of the used vehicles, the total distance, the
+// maximal makespan and the durations of the routes priced by their vehicles
+type Weights struct {
+	Vehicles int
+	Distance int
+	MakeSpan int
+	Cost     int
+}
+
 type VNS struct {
 	IterMax     int
 	LevelMax    int
@@ -56,6 +83,8 @@ const (
 	Const2Opt LocalSearch = "2opt"
 	Shifting  LocalSearch = "shifting"
 	VND       LocalSearch = "vnd"
+	// Blocks moves pickups with the pairs nested up to their deliveries
+	Blocks LocalSearch = "blocks"
 )
 
 // LoadConfig loads configuration file
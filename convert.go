@@ -0,0 +1,71 @@
+package main
+
+import (
+	"os"
+	"path"
+
+	"github.com/mitas1/psa-core/core"
+	"github.com/spf13/pflag"
+)
+
+// convert writes the given instance files in the psa or json format
+func convert(args []string) {
+	flags := pflag.NewFlagSet("convert", pflag.ExitOnError)
+
+	format := flags.StringP("format", "F", string(core.HosnyFormat),
+		"Format of instance files (psa, lilim, json, hosny, wan-rong-jih, tsptw, tsptw-coords).")
+	to := flags.StringP("to", "T", string(core.PSAFormat), "Output format (psa, json).")
+	output := flags.StringP("output", "o", "",
+		"Path to output dir, converted files are written next to the originals if empty.")
+	metricName := flags.StringP("metric", "m", core.RoundedEuclideanMetric,
+		"Distance metric of instances with coordinates.")
+	speed := flags.Float64("speed", 1, "Speed in kilometers per time unit used by the haversine metric.")
+	flags.Parse(args)
+
+	metric, err := core.NewMetric(*metricName, *speed)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if *to != string(core.PSAFormat) && *to != string(core.JSONFormat) {
+		log.Fatalf("Unsupported output format: %v", *to)
+	}
+
+	for _, name := range flags.Args() {
+		tsp, err := readInstance(name, core.Format(*format), metric)
+		if err != nil {
+			log.Error(err)
+			continue
+		}
+
+		dir := *output
+		if dir == "" {
+			dir = path.Dir(name)
+		}
+
+		if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+			log.Fatal(err)
+		}
+
+		outName := path.Join(dir, path.Base(name)+"."+*to)
+
+		file, err := os.Create(outName)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		if *to == string(core.JSONFormat) {
+			err = tsp.WriteJSON(file)
+		} else {
+			err = tsp.Write(file)
+		}
+		file.Close()
+
+		if err != nil {
+			log.Errorf("%v: %v", outName, err)
+			continue
+		}
+
+		log.Infof("Instance converted: %v", outName)
+	}
+}
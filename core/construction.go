@@ -2,6 +2,7 @@ package core
 
 import (
 	"math/rand"
+	"sort"
 
 	"github.com/mitas1/psa-core/config"
 	"github.com/mitas1/psa-core/utils"
@@ -47,10 +48,16 @@ func NewCons(opts config.Construction) *Construction {
 }
 
 func (c *Construction) process(tsp *PDPTW) *Solution {
-	level := 1
-
 	// generate first solution, using configured strategy
-	x := c.strategy.getSolution(tsp)
+	return c.repair(c.strategy.getSolution(tsp), func() *Solution {
+		return c.strategy.getSolution(tsp)
+	})
+}
+
+// repair perturbs and shifts x until it is feasible, restart returns a new
+// starting solution when the perturbation level exceeds levelMax
+func (c *Construction) repair(x *Solution, restart func() *Solution) *Solution {
+	level := 1
 
 	// c.localSearch(x)
 
@@ -78,7 +85,7 @@ func (c *Construction) process(tsp *PDPTW) *Solution {
 
 			if c.levelMax < level {
 				level = 1
-				x = c.strategy.getSolution(tsp)
+				x = restart()
 			}
 		}
 	}
@@ -86,6 +93,83 @@ func (c *Construction) process(tsp *PDPTW) *Solution {
 	return x
 }
 
+// processPlan distributes the requests ordered by due date over the vehicles,
+// every request is inserted into the route with the least cost increase or
+// to the cheapest unused vehicle. Requests fitting nowhere are appended to the route
+// with the least penalty, such routes are repaired at the end. Optional
+// requests fitting nowhere or costing more than their rejection stay unserved.
+func (c *Construction) processPlan(tsp *PDPTW, objective planObjective) *Plan {
+	p := newPlan(tsp)
+
+	due := func(request int) int {
+		nodes := p.requests[request]
+		return tsp.due(nodes[len(nodes)-1])
+	}
+	order := make([]int, len(p.requests))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return due(order[i]) < due(order[j])
+	})
+
+	for _, request := range order {
+		var best *Solution
+		bestIndex, bestCost := -1, 0
+
+		for i, route := range p.routes {
+			x := p.insert(route, request, objective)
+			if x == nil {
+				continue
+			}
+			if cost := objective.route(x) - objective.route(route); best == nil || cost < bestCost {
+				best, bestIndex, bestCost = x, i, cost
+			}
+		}
+
+		unused := p.unused()
+		for _, empty := range unused {
+			if x := p.insert(empty, request, objective); x != nil {
+				cost := objective.vehicles + objective.route(x) - objective.route(empty)
+				if best == nil || cost < bestCost {
+					best, bestIndex, bestCost = x, len(p.routes), cost
+				}
+			}
+		}
+
+		if rejection, ok := tsp.rejection[p.requests[request][0]]; ok &&
+			(best == nil || bestCost >= rejection) {
+			continue
+		}
+
+		if best == nil {
+			if len(unused) > 0 {
+				best, bestIndex = p.append(unused[0], request), len(p.routes)
+			} else {
+				for i, route := range p.routes {
+					x := p.append(route, request)
+					if best == nil || c.Penalty(x) < bestCost {
+						best, bestIndex, bestCost = x, i, c.Penalty(x)
+					}
+				}
+			}
+		}
+
+		if bestIndex == len(p.routes) {
+			p.routes = append(p.routes, best)
+		} else {
+			p.routes[bestIndex] = best
+		}
+	}
+
+	for i, route := range p.routes {
+		if !route.IsFeasible() {
+			p.routes[i] = c.repair(route, route.shuffle)
+		}
+	}
+	return p
+}
+
 func (c *Construction) localSearch(s *Solution) {
 	penalty := 1
 
@@ -130,7 +214,7 @@ func (c *Construction) shifting(s *Solution, direction Direction, setType SetTyp
 
 		if direction == BACKWARD {
 			for i := npos - 1; i > 0; i-- {
-				if !s.tsp.arcs[n][s.route[i]] {
+				if !s.tsp.hasArc(n, s.route[i]) {
 					break
 				}
 
@@ -146,7 +230,7 @@ func (c *Construction) shifting(s *Solution, direction Direction, setType SetTyp
 			}
 		} else {
 			for i := npos + 1; i < len(s.route)-1; i++ {
-				if !s.tsp.arcs[s.route[i]][n] {
+				if !s.tsp.hasArc(s.route[i], n) {
 					break
 				}
 
@@ -186,62 +270,61 @@ func (*Construction) disturb(s *Solution, level int) *Solution {
 	newSolution := s.Copy()
 
 	for i := 0; i < level; i++ {
-		n1 = utils.Random(1, len(s.route))
-		n2 = utils.Random(1, len(s.route))
+		n1 = utils.Random(1, s.movable())
+		n2 = utils.Random(1, s.movable())
 		newSolution.exchange(n1, n2)
 	}
 	return newSolution
 }
 
 // Penalty is sum of all differences between the time to reach each customer
-// and its due date
+// and its due date, ride times above their limits and the excess of the
+// maximum duration and the break are added to them. Items unloaded out of the
+// loading order are penalized as precedence.
 func (c Construction) Penalty(s *Solution) (penalty int) {
-	traveled := s.tsp.traveled
-	carrying := s.tsp.carrying
-	hasNode := false
+	v := s.tsp.vehicleAt(s.vehicle)
+	traveled := s.startTime()
+	carrying := v.Carrying.copy()
+	arrival := traveled
+	position := s.positions()
 
 	p_tw := 0
 	p_pd := 0
 	p_c := 0
 
 	for i := 1; i < len(s.route); i++ {
-
-		hasNode = false
-		traveled += s.tsp.matrix[s.route[i-1]][s.route[i]]
-		carrying += s.tsp.demands[s.route[i-1]]
+		traveled += s.tsp.serviceTime[s.route[i-1]] + s.tsp.travel(s.route[i-1], s.route[i])
+		carrying.add(s.tsp.demands[s.route[i-1]])
+		arrival = traveled
 
 		// wait to ready to time
-		if traveled < s.tsp.readyTime[s.route[i]] {
-			traveled = s.tsp.readyTime[s.route[i]]
-		}
+		traveled = s.tsp.start(s.route[i], traveled)
 
-		if carrying > s.tsp.capacity {
-			p_c = p_c + (carrying - s.tsp.capacity)
-		}
+		// sum over the dimensions of the capacity
+		p_c = p_c + carrying.excess(v.Capacity)
 
-		if value, ok := s.tsp.precedence[s.route[i]]; ok {
-			for j := i; j > 0; j-- {
-				if value == s.route[j] {
-					hasNode = true
-					break
-				}
-			}
-			if !hasNode {
-				for j := i; j < s.tsp.numNodes; j++ {
-					if value == s.route[j] {
-						p_pd = p_pd + j
-						break
-					}
-				}
+		// predecessors visited later
+		for _, node := range s.tsp.before[s.route[i]] {
+			if position[node] > i {
+				p_pd = p_pd + position[node]
 			}
 		}
 
-		if s.tsp.dueDate[s.route[i]] != 0 && s.tsp.dueDate[s.route[i]] < traveled ||
-			s.tsp.readyTime[s.route[i]] > traveled {
-			p_tw = p_tw + traveled - s.tsp.dueDate[s.route[i]]
+		if due := s.tsp.due(s.route[i]); due < traveled {
+			p_tw = p_tw + traveled - due
 		}
 	}
 
+	// end of the shift
+	if v.Due != 0 && arrival > v.Due {
+		p_tw = p_tw + arrival - v.Due
+	}
+
+	// ride times and shifts are weighted as time windows, loading order as
+	// precedence
+	p_tw = p_tw + s.rideExcess() + s.shiftExcess()
+	p_pd = p_pd + s.loadingViolations()
+
 	penalty = c.penalty.TimeWindows*p_tw + c.penalty.PickupDelivery*p_pd + c.penalty.Capacity*p_c
 	return
 }
@@ -0,0 +1,38 @@
+package core
+
+// benchmarkTask is a pickup and delivery pair of benchmarks which locate
+// tasks by coordinates
+type benchmarkTask struct {
+	pickup, delivery           Point
+	demand                     int
+	pickupReady, pickupDue     int
+	deliveryReady, deliveryDue int
+}
+
+// buildPairInstance returns instance with the depot as node 0 followed by the
+// pickup 2i-1 and delivery 2i of every task, matrix is computed by metric
+func buildPairInstance(name string, capacity int, depot Point, depotReady, depotDue int,
+	tasks []benchmarkTask, metric Metric) (*PDPTW, error) {
+	tsp := newInstance(name, 2*len(tasks)+1)
+	tsp.capacity = Load{capacity}
+	tsp.readyTime[0] = depotReady
+	tsp.dueDate[0] = depotDue
+
+	coords := make([]Point, tsp.numNodes)
+	coords[0] = depot
+
+	for i, task := range tasks {
+		pickup, delivery := 2*i+1, 2*i+2
+
+		coords[pickup] = task.pickup
+		coords[delivery] = task.delivery
+
+		tsp.setPair(pickup, delivery, Load{task.demand}, task.pickupReady, task.pickupDue,
+			task.deliveryReady, task.deliveryDue)
+	}
+
+	if err := tsp.SetCoordinates(coords, metric); err != nil {
+		return nil, &ParseError{File: name, Field: "coordinates", Err: err}
+	}
+	return tsp, nil
+}
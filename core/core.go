@@ -17,11 +17,22 @@ type result struct {
 	err      error
 }
 
+type planResult struct {
+	plan *Plan
+	err  error
+}
+
 type Core struct {
 	common       config.Common
 	cons         *Construction
 	optimization optimization
 	objective    objective
+	// search is the local search of the optimization
+	search        localSearch
+	planSearch    planVNS
+	planObjective planObjective
+	// soft are the default costs of soft windows, nil if they are hard
+	soft *SoftWindow
 }
 
 func NewCore(c *config.Config) *Core {
@@ -30,23 +41,55 @@ func NewCore(c *config.Config) *Core {
 	objective := NewObjective(c.Optimization)
 
 	var optimization optimization
+	local := c.Optimization.SA.LocalSearch
 
 	if c.Optimization.VNS != (config.VNS{}) {
 		optimization = NewVNS(c.Optimization.VNS, objective)
+		local = c.Optimization.VNS.LocalSearch
 	} else {
 		optimization = NewSA(c.Optimization.SA, objective)
 	}
-	return &Core{cons: cons, optimization: optimization, objective: objective, common: c.Common}
+	planObjective := newPlanObjective(c.Optimization)
+
+	return &Core{cons: cons, optimization: optimization, objective: objective, common: c.Common,
+		search:     getLocalSearch(local, objective),
+		planSearch: newPlanVNS(c.Optimization, planObjective), planObjective: planObjective,
+		soft: softWindow(c.Optimization)}
+}
+
+// prepare returns copy of the instance with the soft windows of the core,
+// tightened time windows and eliminated incompatible arcs, the instance itself
+// is kept. Returns error if the instance is infeasible.
+func (c Core) prepare(tsp *PDPTW) (*PDPTW, error) {
+	work := tsp.clone()
+	work.origin = tsp.original()
+
+	if c.soft != nil {
+		work.SoftenWindows(*c.soft)
+	}
+
+	report := work.Preprocess()
+	log.Infof("Preprocessed: %d of %d arcs removed, windows tightened by %d ready and %d due units in %d passes",
+		report.ArcsRemoved, report.Arcs, report.ReadyTightened, report.DueTightened, report.Passes)
+	if report.Infeasible != nil {
+		return nil, fmt.Errorf("infeasible instance: nodes %v cannot be served in time",
+			report.Infeasible)
+	}
+	return work, nil
 }
 
-// Process PDPTW instance
+// Process PDPTW instance, the solution refers to a preprocessed copy of the
+// instance
 func (c Core) Process(tsp *PDPTW) (*Solution, error) {
 	iterationMax := c.common.IterMax
 	i := 0
 	iteration := 0
 
-	// Preprocess incompatible arcs
-	tsp.preprocess()
+	// Tighten time windows and eliminate incompatible arcs
+	tsp, err := c.prepare(tsp)
+	if err != nil {
+		return nil, err
+	}
 
 	// init structs
 	var best, s *Solution
@@ -60,6 +103,8 @@ func (c Core) Process(tsp *PDPTW) (*Solution, error) {
 
 			//Generate feasible solution
 			s = c.cons.process(tsp)
+			// serve the optional requests which pay off
+			s = newLocalRequests(c.objective).initial(s)
 			channel <- result{solution: s, err: nil}
 
 			// Try to improve
@@ -94,3 +139,56 @@ func (c Core) Process(tsp *PDPTW) (*Solution, error) {
 	}
 	return best, nil
 }
+
+// ProcessPlan solves PDPTW instance with several vehicles, the plan refers to
+// a preprocessed copy of the instance
+func (c Core) ProcessPlan(tsp *PDPTW) (*Plan, error) {
+	iterationMax := c.common.IterMax
+	iteration := 0
+
+	tsp, err := c.prepare(tsp)
+	if err != nil {
+		return nil, err
+	}
+
+	var best *Plan
+
+	channel := make(chan planResult)
+
+	for i := 0; i < iterationMax; i++ {
+		go func() {
+			// set random seed
+			rand.Seed(time.Now().UnixNano())
+
+			// Distribute requests over vehicles
+			p := c.cons.processPlan(tsp, c.planObjective)
+			channel <- planResult{plan: p}
+
+			// Try to improve
+			p = c.planSearch.process(p)
+			channel <- planResult{plan: p}
+		}()
+	}
+
+	timeout := time.After(c.common.MaxTime * time.Second)
+
+	for iteration < iterationMax*2 {
+		iteration++
+		select {
+		case res := <-channel:
+			if res.err != nil {
+				return nil, res.err
+			}
+
+			if best == nil || c.planObjective.better(res.plan, best) {
+				best = res.plan
+			}
+		case <-timeout:
+			if best == nil {
+				return nil, fmt.Errorf("timeout: Unable to find solution")
+			}
+			return best, fmt.Errorf("timeout: Only partial solution found")
+		}
+	}
+	return best, nil
+}
@@ -6,20 +6,25 @@ import (
 	"sort"
 )
 
-type random struct{}
-
-// generate random solution
-func (random) getSolution(tsp *PDPTW) *Solution {
-	route := []int{tsp.startNode}
+// innerNodes returns all nodes but the start and the end node of the first
+// vehicle and the nodes of optional requests
+func (tsp *PDPTW) innerNodes() (nodes []int) {
+	v := tsp.vehicleAt(0)
 	for i := 0; i < tsp.numNodes; i++ {
-		if i != tsp.startNode {
-			route = append(route, i)
+		if i != v.Start && i != v.End && !tsp.isOptional(i) {
+			nodes = append(nodes, i)
 		}
 	}
+	return
+}
 
-	for i := 1; i < len(route); i++ {
-		j := rand.Intn(i) + 1
-		route[i], route[j] = route[j], route[i]
+// newRoute returns solution of the first vehicle visiting its start node, the
+// given nodes and its end node if any
+func (tsp *PDPTW) newRoute(nodes []int) *Solution {
+	v := tsp.vehicleAt(0)
+	route := append([]int{v.Start}, nodes...)
+	if v.End >= 0 {
+		route = append(route, v.End)
 	}
 
 	s := NewSolution(tsp, route)
@@ -27,39 +32,42 @@ func (random) getSolution(tsp *PDPTW) *Solution {
 	return &s
 }
 
-type sortBydueDate struct{}
+type random struct{}
 
-func (sortBydueDate) getSolution(tsp *PDPTW) *Solution {
-	var route []int
+// generate random solution
+func (random) getSolution(tsp *PDPTW) *Solution {
+	route := tsp.innerNodes()
 
-	for i := 0; i < tsp.numNodes; i++ {
-		if i != tsp.startNode {
-			route = append(route, i)
-		}
+	for i := 1; i < len(route); i++ {
+		j := rand.Intn(i + 1)
+		route[i], route[j] = route[j], route[i]
 	}
 
+	return tsp.newRoute(route)
+}
+
+type sortBydueDate struct{}
+
+func (sortBydueDate) getSolution(tsp *PDPTW) *Solution {
+	route := tsp.innerNodes()
+
 	// sort by dueDate
 	sort.Slice(route, func(i, j int) bool {
 		return tsp.dueDate[route[i]] < tsp.dueDate[route[j]]
 	})
 
-	s := NewSolution(tsp, append([]int{tsp.startNode}, route...))
-
-	return &s
+	return tsp.newRoute(route)
 }
 
 type sortByTW struct{}
 
 func (sortByTW) getSolution(tsp *PDPTW) *Solution {
-	route := []int{}
+	route := tsp.innerNodes()
 
 	median := make(map[int]int)
 
-	for i := 0; i < tsp.numNodes; i++ {
-		if i != tsp.startNode {
-			route = append(route, i)
-			median[i] = tsp.dueDate[i] - ((tsp.dueDate[i] - tsp.readyTime[i]) / 2)
-		}
+	for _, i := range route {
+		median[i] = tsp.dueDate[i] - ((tsp.dueDate[i] - tsp.readyTime[i]) / 2)
 	}
 
 	// sort by median
@@ -67,68 +75,81 @@ func (sortByTW) getSolution(tsp *PDPTW) *Solution {
 		return median[route[i]] < median[route[j]]
 	})
 
-	s := NewSolution(tsp, append([]int{tsp.startNode}, route...))
-
-	return &s
+	return tsp.newRoute(route)
 }
 
 type greedy struct{}
 
 // returns solution constructed by nearest neighborhood heuristic
 func (greedy) getSolution(tsp *PDPTW) *Solution {
-	best := NewSolution(tsp, []int{0})
-	for i := 0; i < tsp.numNodes-1; i++ {
-		current := best.getNode(i)
-		minIndex := 0
+	inner := tsp.innerNodes()
+	visited := make([]bool, tsp.numNodes)
+	route := make([]int, 0, len(inner))
+
+	current := tsp.vehicleAt(0).Start
+	for range inner {
+		next := -1
 		var min = math.MaxInt64
 
-		for index, value := range tsp.matrix[current] {
-			if !best.hasNode(index) && value < min {
+		for _, node := range inner {
+			value := tsp.travel(current, node)
+			if !visited[node] && value < min {
 				min = value
-				minIndex = index
+				next = node
 			}
 		}
 
-		best.addNode(minIndex)
+		visited[next] = true
+		route = append(route, next)
+		current = next
 	}
-	best.addNode(0)
-	return &best
+	return tsp.newRoute(route)
 }
 
+// GetRandomPD returns route of the first vehicle visiting the nodes in random
+// order which keeps the precedence, e.g. all pickups before the deliveries
 func GetRandomPD(tsp *PDPTW) *Solution {
-	r1 := []int{0}
-	r2 := []int{}
-
-	tmp := make(map[int]bool, tsp.numNodes)
-
-	for i := 0; i < tsp.numNodes; i++ {
-		if value, ok := tsp.precedence[i]; ok {
-			r1 = append(r1, value)
-			r2 = append(r2, i)
-			tmp[i] = true
-			tmp[tsp.precedence[i]] = true
+	placed := make([]bool, tsp.numNodes)
+	placed[tsp.vehicleAt(0).Start] = true
+
+	nodes := tsp.innerNodes()
+	route := make([]int, 0, len(nodes))
+	for len(nodes) > 0 {
+		// the nodes whose predecessors are placed follow in random order
+		var ready, rest []int
+		for _, node := range nodes {
+			if tsp.predecessorsIn(node, placed) {
+				ready = append(ready, node)
+			} else {
+				rest = append(rest, node)
+			}
 		}
-	}
-
-	/* 	for i := 1; i < tsp.numNodes; i++ {
-		if _, ok := tmp[i]; !ok {
-			r1 = append(r1, i)
+		if ready == nil {
+			// predecessors out of the route cannot be kept
+			ready, rest = rest, nil
 		}
-	} */
-
-	for i := 1; i < len(r1); i++ {
-		j := rand.Intn(i) + 1
-		r1[i], r1[j] = r1[j], r1[i]
-	}
 
-	for i := 1; i < len(r2); i++ {
-		j := rand.Intn(i) + 1
-		r2[i], r2[j] = r2[j], r2[i]
+		for i := 1; i < len(ready); i++ {
+			j := rand.Intn(i + 1)
+			ready[i], ready[j] = ready[j], ready[i]
+		}
+		for _, node := range ready {
+			placed[node] = true
+		}
+		route = append(route, ready...)
+		nodes = rest
 	}
 
-	r1 = append(r1, r2...)
-
-	s := NewSolution(tsp, r1)
+	return tsp.newRoute(route)
+}
 
-	return &s
+// predecessorsIn reports whether all nodes which must be visited before the
+// node are placed
+func (tsp *PDPTW) predecessorsIn(node int, placed []bool) bool {
+	for _, pred := range tsp.before[node] {
+		if !placed[pred] {
+			return false
+		}
+	}
+	return true
 }
@@ -0,0 +1,188 @@
+package core
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+)
+
+// GeneratorOptions are the knobs of GenerateInstance
+type GeneratorOptions struct {
+	Seed int64
+	// Pairs is the number of pickup and delivery pairs
+	Pairs int
+	// CapacityTightness in [0, 1], 1 sets the capacity to the maximal load of
+	// the hidden feasible route, 0 to the total demand
+	CapacityTightness float64
+	// WindowWidth is the width of every time window
+	WindowWidth int
+	// Horizon is the planning horizon the hidden route is scaled to
+	Horizon int
+	// Clusters of locations, 0 spreads locations uniformly
+	Clusters int
+	// Asymmetry in [0, 1] is the maximal relative increase of an arc
+	Asymmetry float64
+	// MaxDemand of a pair
+	MaxDemand int
+}
+
+// GenerateInstance returns a random single vehicle instance which is feasible
+// by construction. Locations are placed first, then a hidden route respecting
+// the precedence is drawn and the time windows are placed around its arrival
+// times. Node 0 is the depot, pair i consists of nodes 2i-1 and 2i.
+func GenerateInstance(opts GeneratorOptions) (*PDPTW, error) {
+	if opts.Pairs <= 0 {
+		return nil, fmt.Errorf("number of pairs must be positive, got %d", opts.Pairs)
+	}
+	if opts.Horizon <= 0 {
+		return nil, fmt.Errorf("horizon must be positive, got %d", opts.Horizon)
+	}
+	if opts.CapacityTightness < 0 || opts.CapacityTightness > 1 {
+		return nil, fmt.Errorf("capacity tightness must be in [0, 1], got %v",
+			opts.CapacityTightness)
+	}
+	if opts.Asymmetry < 0 || opts.Asymmetry > 1 {
+		return nil, fmt.Errorf("asymmetry must be in [0, 1], got %v", opts.Asymmetry)
+	}
+	if opts.MaxDemand <= 0 {
+		opts.MaxDemand = 10
+	}
+
+	rnd := rand.New(rand.NewSource(opts.Seed))
+	numNodes := 2*opts.Pairs + 1
+
+	tsp := newInstance(fmt.Sprintf("gen-%d-%d", opts.Pairs, opts.Seed), numNodes)
+
+	// random order route is about half of the side per leg
+	side := math.Max(1, 2*float64(opts.Horizon)/float64(numNodes))
+	coords := generatePoints(rnd, numNodes, side, opts.Clusters)
+
+	if opts.Asymmetry > 0 {
+		matrix, err := buildMatrix(coords, RoundedEuclidean{})
+		if err != nil {
+			return nil, err
+		}
+		tsp.coords = coords
+		tsp.matrix = matrix
+		for i := 0; i < numNodes; i++ {
+			for j := 0; j < numNodes; j++ {
+				increase := opts.Asymmetry * rnd.Float64() * float64(tsp.travel(i, j))
+				travel := tsp.travel(i, j) + int(math.Round(increase))
+				if err := checkTravel(travel); err != nil {
+					return nil, err
+				}
+				tsp.matrix.set(i, j, travel)
+			}
+		}
+	} else if err := tsp.SetCoordinates(coords, RoundedEuclidean{}); err != nil {
+		return nil, err
+	}
+
+	demands := make([]int, opts.Pairs+1)
+	for pair := 1; pair <= opts.Pairs; pair++ {
+		demands[pair] = rnd.Intn(opts.MaxDemand) + 1
+	}
+
+	// hidden route
+	route := generateRoute(rnd, opts.Pairs)
+
+	arrival := make([]int, numNodes)
+	load, maxLoad, total := 0, 0, 0
+
+	for i := 1; i < len(route); i++ {
+		arrival[route[i]] = arrival[route[i-1]] + tsp.travel(route[i-1], route[i])
+
+		if route[i]%2 == 1 {
+			load += demands[(route[i]+1)/2]
+			total += demands[(route[i]+1)/2]
+		} else {
+			load -= demands[route[i]/2]
+		}
+
+		if load > maxLoad {
+			maxLoad = load
+		}
+	}
+
+	tsp.capacity = Load{maxLoad + int(math.Round((1-opts.CapacityTightness)*float64(total-maxLoad)))}
+
+	window := func(node int) (ready, due int) {
+		ready = arrival[node]
+		if opts.WindowWidth > 0 {
+			ready -= rnd.Intn(opts.WindowWidth + 1)
+		}
+		if ready < 0 {
+			ready = 0
+		}
+		return ready, ready + opts.WindowWidth
+	}
+
+	for pair := 1; pair <= opts.Pairs; pair++ {
+		pickup, delivery := 2*pair-1, 2*pair
+		pickupReady, pickupDue := window(pickup)
+		deliveryReady, deliveryDue := window(delivery)
+
+		tsp.setPair(pickup, delivery, Load{demands[pair]}, pickupReady, pickupDue,
+			deliveryReady, deliveryDue)
+	}
+
+	// depot
+	tsp.dueDate[0] = opts.Horizon
+	if last := arrival[route[len(route)-1]]; last > opts.Horizon {
+		tsp.dueDate[0] = last
+	}
+
+	return tsp, nil
+}
+
+// generatePoints places points into square with the given side, uniformly or
+// normally around the cluster centers
+func generatePoints(rnd *rand.Rand, n int, side float64, clusters int) []Point {
+	points := make([]Point, n)
+
+	clamp := func(x float64) float64 {
+		return math.Max(0, math.Min(side, x))
+	}
+
+	centers := make([]Point, clusters)
+	for i := range centers {
+		centers[i] = Point{X: rnd.Float64() * side, Y: rnd.Float64() * side}
+	}
+
+	for i := range points {
+		if clusters > 0 {
+			center := centers[rnd.Intn(clusters)]
+			points[i] = Point{
+				X: clamp(center.X + rnd.NormFloat64()*side/10),
+				Y: clamp(center.Y + rnd.NormFloat64()*side/10),
+			}
+		} else {
+			points[i] = Point{X: rnd.Float64() * side, Y: rnd.Float64() * side}
+		}
+	}
+	return points
+}
+
+// generateRoute returns random route starting in depot which visits every
+// pickup 2i-1 before its delivery 2i
+func generateRoute(rnd *rand.Rand, pairs int) []int {
+	route := []int{0}
+	waiting := rnd.Perm(pairs)
+	open := []int{}
+
+	for len(waiting) > 0 || len(open) > 0 {
+		if len(open) == 0 || (len(waiting) > 0 && rnd.Intn(2) == 0) {
+			pair := waiting[len(waiting)-1] + 1
+			waiting = waiting[:len(waiting)-1]
+			open = append(open, pair)
+			route = append(route, 2*pair-1)
+		} else {
+			k := rnd.Intn(len(open))
+			pair := open[k]
+			open[k] = open[len(open)-1]
+			open = open[:len(open)-1]
+			route = append(route, 2*pair)
+		}
+	}
+	return route
+}
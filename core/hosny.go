@@ -0,0 +1,152 @@
+package core
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+)
+
+type hosnyLocation struct {
+	line   int
+	point  Point
+	demand int
+	ready  int
+	due    int
+}
+
+var hosnyFields = []string{"id", "x", "y", "demand", "readyTime", "dueDate"}
+
+// ParseHosny reads the single vehicle PDPTW benchmark of Hosny. The file
+// starts with "capacity [requests]" followed by the depot, the pickups and the
+// deliveries, pickup i is paired with delivery i+requests. Some files omit the
+// number of requests, it is then derived from the number of locations. The depot becomes
+// node 0, pickup i node 2i-1 and its delivery node 2i, the matrix is computed
+// by metric (RoundedEuclidean if nil).
+func ParseHosny(name string, r io.Reader, metric Metric) (*PDPTW, error) {
+	if metric == nil {
+		metric = RoundedEuclidean{}
+	}
+
+	p := hosnyParser{lineParser: lineParser{name: name}, locations: make(map[int]*hosnyLocation)}
+
+	if err := p.scan(r, p.parseLine); err != nil {
+		return nil, err
+	}
+
+	return p.build(metric)
+}
+
+type hosnyParser struct {
+	lineParser
+	header    bool
+	capacity  int
+	requests  int
+	locations map[int]*hosnyLocation
+}
+
+func (p *hosnyParser) parseLine(fields []string) error {
+	if !p.header {
+		if len(fields) != 1 && len(fields) != 2 {
+			return p.errorf("header", fmt.Errorf("%w: expected capacity and number of requests",
+				ErrHeader))
+		}
+		elems, err := p.ints(fields, func(i int) string {
+			return []string{"capacity", "requests"}[i]
+		})
+		if err != nil {
+			return err
+		}
+		p.capacity = elems[0]
+		if len(elems) > 1 {
+			if elems[1] <= 0 {
+				return p.errorf("requests", fmt.Errorf("%w: %d", ErrHeader, elems[1]))
+			}
+			p.requests = elems[1]
+		}
+		p.header = true
+		return nil
+	}
+
+	if len(fields) != len(hosnyFields) {
+		return p.errorf("location", fmt.Errorf("%w: expected %d fields, got %d",
+			ErrTaskFormat, len(hosnyFields), len(fields)))
+	}
+
+	id, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return p.errorf("id", fmt.Errorf("%w %q", ErrSyntax, fields[0]))
+	}
+	if id < 0 || (p.requests > 0 && id > 2*p.requests) {
+		return p.errorf("id", fmt.Errorf("%w: %d not in [0, %d]", ErrNodeRange, id, 2*p.requests))
+	}
+	if _, ok := p.locations[id]; ok {
+		return p.errorf("id", fmt.Errorf("%w: %d", ErrDuplicateNode, id))
+	}
+
+	location := hosnyLocation{line: p.line}
+
+	if location.point.X, err = p.float("x", fields[1]); err != nil {
+		return err
+	}
+	if location.point.Y, err = p.float("y", fields[2]); err != nil {
+		return err
+	}
+
+	elems, err := p.ints(fields[3:], func(i int) string { return hosnyFields[i+3] })
+	if err != nil {
+		return err
+	}
+
+	location.demand, location.ready, location.due = elems[0], elems[1], elems[2]
+	p.locations[id] = &location
+	return nil
+}
+
+func (p *hosnyParser) build(metric Metric) (*PDPTW, error) {
+	if !p.header {
+		return nil, &ParseError{File: p.name, Field: "header", Err: ErrHeader}
+	}
+
+	if p.requests == 0 {
+		if len(p.locations)%2 == 0 {
+			return nil, &ParseError{File: p.name, Field: "requests", Err: fmt.Errorf(
+				"%w: %d locations do not form depot and pairs", ErrDimension, len(p.locations))}
+		}
+		p.requests = len(p.locations) / 2
+	}
+
+	for id := 0; id <= 2*p.requests; id++ {
+		if _, ok := p.locations[id]; !ok {
+			return nil, &ParseError{File: p.name, Field: "id", Err: fmt.Errorf(
+				"%w: %d", ErrUndefinedNode, id)}
+		}
+	}
+
+	tasks := make([]benchmarkTask, p.requests)
+
+	for i := range tasks {
+		pickup := p.locations[i+1]
+		delivery := p.locations[i+1+p.requests]
+
+		if delivery.demand != -pickup.demand {
+			return nil, &ParseError{File: p.name, Line: delivery.line, Field: "demand",
+				Err: fmt.Errorf("%w: delivery demand %d does not cancel pickup demand %d",
+					ErrTaskFormat, delivery.demand, pickup.demand)}
+		}
+
+		tasks[i] = benchmarkTask{
+			pickup:        pickup.point,
+			delivery:      delivery.point,
+			demand:        pickup.demand,
+			pickupReady:   pickup.ready,
+			pickupDue:     pickup.due,
+			deliveryReady: delivery.ready,
+			deliveryDue:   delivery.due,
+		}
+	}
+
+	depot := p.locations[0]
+
+	return buildPairInstance(p.name, p.capacity, depot.point, depot.ready, depot.due,
+		tasks, metric)
+}
@@ -0,0 +1,550 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// jsonInstance is the JSON representation of PDPTW. Matrix may be omitted if
+// all nodes have coordinates and metric is given.
+type jsonInstance struct {
+	Name      string `json:"name,omitempty"`
+	StartNode int    `json:"startNode"`
+	// EndNode is omitted for open routes
+	EndNode *int `json:"endNode,omitempty"`
+	// Vehicles is omitted for a single vehicle, Fleet for identical ones
+	Vehicles int           `json:"vehicles,omitempty"`
+	Fleet    []jsonVehicle `json:"fleet,omitempty"`
+	// Loading is the unloading order of the pairs, lifo or fifo
+	Loading Loading `json:"loading,omitempty"`
+	// MaxDuration limits the duration of every route, Break is the rest of
+	// the drivers
+	MaxDuration int        `json:"maxDuration,omitempty"`
+	Break       *jsonBreak `json:"break,omitempty"`
+	// Capacity, Carrying and demands are numbers or arrays with an amount per
+	// dimension of the load
+	Capacity Load       `json:"capacity"`
+	Traveled int        `json:"traveled,omitempty"`
+	Carrying Load       `json:"carrying,omitempty"`
+	Metric   string     `json:"metric,omitempty"`
+	Speed    float64    `json:"speed,omitempty"`
+	Nodes    []jsonNode `json:"nodes"`
+	Pairs    []jsonPair `json:"pairs,omitempty"`
+	// Precedences are rules besides the pairs
+	Precedences []jsonPrecedence `json:"precedences,omitempty"`
+	Matrix      [][]int          `json:"matrix,omitempty"`
+}
+
+type jsonNode struct {
+	ID          int      `json:"id"`
+	ReadyTime   int      `json:"readyTime"`
+	DueDate     int      `json:"dueDate"`
+	ServiceTime int      `json:"serviceTime,omitempty"`
+	Demand      Load     `json:"demand"`
+	X           *float64 `json:"x,omitempty"`
+	Y           *float64 `json:"y,omitempty"`
+	// Windows replace readyTime and dueDate of nodes with several windows
+	Windows []jsonWindow `json:"windows,omitempty"`
+	// Soft are costs of the service outside of the windows solved as soft
+	Soft *jsonSoftWindow `json:"soft,omitempty"`
+	// Rejection makes the request of the pickup or single node optional
+	Rejection int `json:"rejection,omitempty"`
+}
+
+type jsonSoftWindow struct {
+	Earliness int                `json:"earliness"`
+	Lateness  int                `json:"lateness"`
+	Steps     []jsonLatenessStep `json:"steps,omitempty"`
+}
+
+type jsonLatenessStep struct {
+	After    int `json:"after"`
+	Lateness int `json:"lateness"`
+}
+
+type jsonWindow struct {
+	ReadyTime int `json:"readyTime"`
+	DueDate   int `json:"dueDate"`
+}
+
+type jsonPair struct {
+	Pickup   int `json:"pickup"`
+	Delivery int `json:"delivery"`
+	// MaxRideTime limits the time from the pickup to the delivery
+	MaxRideTime int `json:"maxRideTime,omitempty"`
+}
+
+// jsonVehicle is the JSON representation of Vehicle, the route is open if
+// End is omitted and Cost defaults to 1
+type jsonVehicle struct {
+	Capacity Load `json:"capacity"`
+	Start    int  `json:"start"`
+	End      *int `json:"end,omitempty"`
+	Ready    int  `json:"ready,omitempty"`
+	Due      int  `json:"due,omitempty"`
+	Carrying Load `json:"carrying,omitempty"`
+	Cost     *int `json:"cost,omitempty"`
+}
+
+type jsonBreak struct {
+	Ready    int `json:"ready"`
+	Due      int `json:"due"`
+	Duration int `json:"duration"`
+}
+
+type jsonPrecedence struct {
+	Before int `json:"before"`
+	After  int `json:"after"`
+}
+
+// jsonSolution is the JSON representation of Solution
+type jsonSolution struct {
+	Instance string `json:"instance,omitempty"`
+	// Vehicle serving the route is given in plans only
+	Vehicle  *int   `json:"vehicle,omitempty"`
+	Route    []int  `json:"route"`
+	Schedule []Stop `json:"schedule,omitempty"`
+	MakeSpan int    `json:"makeSpan"`
+	Distance int    `json:"distance"`
+	// WindowCost is the cost of soft windows, omitted for hard ones
+	WindowCost int `json:"windowCost,omitempty"`
+	// Unserved are the pickups and single nodes of the rejected optional
+	// requests, RejectionCost is the sum of their costs
+	Unserved      []int `json:"unserved,omitempty"`
+	RejectionCost int   `json:"rejectionCost,omitempty"`
+	Feasible      bool  `json:"feasible"`
+}
+
+// jsonPlan is the JSON representation of Plan
+type jsonPlan struct {
+	Instance      string         `json:"instance,omitempty"`
+	Routes        []jsonSolution `json:"routes"`
+	Vehicles      int            `json:"vehicles"`
+	MakeSpan      int            `json:"makeSpan"`
+	Distance      int            `json:"distance"`
+	WindowCost    int            `json:"windowCost,omitempty"`
+	Unserved      []int          `json:"unserved,omitempty"`
+	RejectionCost int            `json:"rejectionCost,omitempty"`
+	Feasible      bool           `json:"feasible"`
+}
+
+// ParseJSON reads an instance in the JSON format, name is used when the
+// instance has no name and in the reported errors
+func ParseJSON(name string, r io.Reader) (*PDPTW, error) {
+	var in jsonInstance
+
+	if err := json.NewDecoder(r).Decode(&in); err != nil {
+		return nil, &ParseError{File: name, Err: err}
+	}
+
+	errorf := func(field string, err error) error {
+		return &ParseError{File: name, Field: field, Err: err}
+	}
+
+	if in.Name != "" {
+		name = in.Name
+	}
+
+	numNodes := len(in.Nodes)
+	if numNodes == 0 {
+		return nil, errorf("nodes", fmt.Errorf("%w: no nodes", ErrDimension))
+	}
+
+	tsp := newInstance(name, numNodes)
+	tsp.startNode = in.StartNode
+	tsp.traveled = in.Traveled
+
+	if in.Capacity != nil {
+		if err := tsp.SetCapacity(in.Capacity); err != nil {
+			return nil, errorf("capacity", err)
+		}
+	}
+	if in.Carrying != nil {
+		if len(in.Carrying) != tsp.Dimensions() {
+			return nil, errorf("carrying", fmt.Errorf("%w: expected %d amounts, got %d",
+				ErrDimension, tsp.Dimensions(), len(in.Carrying)))
+		}
+		tsp.carrying = in.Carrying
+	}
+
+	if in.StartNode < 0 || in.StartNode >= numNodes {
+		return nil, errorf("startNode", fmt.Errorf("%w: %d", ErrNodeRange, in.StartNode))
+	}
+
+	defined := make([]bool, numNodes)
+	coords := make([]Point, numNodes)
+	hasCoords := true
+
+	for i, node := range in.Nodes {
+		field := fmt.Sprintf("nodes[%d]", i)
+
+		if node.ID < 0 || node.ID >= numNodes {
+			return nil, errorf(field+".id", fmt.Errorf("%w: %d", ErrNodeRange, node.ID))
+		}
+		if defined[node.ID] {
+			return nil, errorf(field+".id", fmt.Errorf("%w: %d", ErrDuplicateNode, node.ID))
+		}
+		defined[node.ID] = true
+
+		tsp.readyTime[node.ID] = node.ReadyTime
+		tsp.dueDate[node.ID] = node.DueDate
+		tsp.serviceTime[node.ID] = node.ServiceTime
+
+		if node.Windows != nil {
+			windows := make([]Window, len(node.Windows))
+			for k, w := range node.Windows {
+				windows[k] = Window{Ready: w.ReadyTime, Due: w.DueDate}
+			}
+			if err := tsp.SetWindows(node.ID, windows); err != nil {
+				return nil, errorf(field+".windows", err)
+			}
+		}
+		if node.Soft != nil {
+			costs := SoftWindow{Earliness: node.Soft.Earliness, Lateness: node.Soft.Lateness}
+			for _, step := range node.Soft.Steps {
+				costs.Steps = append(costs.Steps, LatenessStep{After: step.After, Lateness: step.Lateness})
+			}
+			if err := tsp.SetSoftWindow(node.ID, costs); err != nil {
+				return nil, errorf(field+".soft", err)
+			}
+		}
+		switch {
+		case node.Demand == nil:
+			if node.ID != tsp.startNode {
+				tsp.demands[node.ID] = make(Load, tsp.Dimensions())
+			}
+		case len(node.Demand) != tsp.Dimensions():
+			return nil, errorf(field+".demand", fmt.Errorf("%w: expected %d amounts, got %d",
+				ErrDimension, tsp.Dimensions(), len(node.Demand)))
+		case node.ID != tsp.startNode || !node.Demand.isZero():
+			tsp.demands[node.ID] = node.Demand
+		}
+
+		if node.X != nil && node.Y != nil {
+			coords[node.ID] = Point{X: *node.X, Y: *node.Y}
+		} else {
+			hasCoords = false
+		}
+	}
+
+	paired := make(map[int]bool)
+
+	for i, pair := range in.Pairs {
+		field := fmt.Sprintf("pairs[%d]", i)
+
+		for _, node := range []int{pair.Pickup, pair.Delivery} {
+			if node < 0 || node >= numNodes {
+				return nil, errorf(field, fmt.Errorf("%w: %d", ErrNodeRange, node))
+			}
+			if paired[node] {
+				return nil, errorf(field, fmt.Errorf("%w: %d", ErrDuplicateNode, node))
+			}
+			paired[node] = true
+		}
+
+		demand := tsp.demands[pair.Pickup]
+		if !demand.cancels(tsp.demands[pair.Delivery]) {
+			return nil, errorf(field, fmt.Errorf(
+				"%w: delivery demand %v does not cancel pickup demand %v",
+				ErrTaskFormat, tsp.demands[pair.Delivery], demand))
+		}
+
+		tsp.setPair(pair.Pickup, pair.Delivery, demand,
+			tsp.readyTime[pair.Pickup], tsp.dueDate[pair.Pickup],
+			tsp.readyTime[pair.Delivery], tsp.dueDate[pair.Delivery])
+
+		if err := tsp.SetMaxRideTime(pair.Pickup, pair.Delivery, pair.MaxRideTime); err != nil {
+			return nil, errorf(field+".maxRideTime", err)
+		}
+	}
+
+	for node := 0; node < numNodes; node++ {
+		if !paired[node] && node != tsp.startNode {
+			tsp.precedence[node] = -1
+		}
+	}
+
+	for i, rule := range in.Precedences {
+		for _, node := range []int{rule.Before, rule.After} {
+			if node < 0 || node >= numNodes {
+				return nil, errorf(fmt.Sprintf("precedences[%d]", i),
+					fmt.Errorf("%w: %d", ErrNodeRange, node))
+			}
+		}
+		tsp.addPrecedence(rule.Before, rule.After)
+	}
+
+	if in.Vehicles != 0 {
+		if err := tsp.SetVehicles(in.Vehicles); err != nil {
+			return nil, errorf("vehicles", err)
+		}
+	}
+
+	if in.EndNode != nil {
+		if err := tsp.SetEndNode(*in.EndNode); err != nil {
+			return nil, errorf("endNode", err)
+		}
+	}
+
+	if err := tsp.SetLoading(in.Loading); err != nil {
+		return nil, errorf("loading", err)
+	}
+
+	if err := tsp.SetMaxDuration(in.MaxDuration); err != nil {
+		return nil, errorf("maxDuration", err)
+	}
+	if b := in.Break; b != nil {
+		if err := tsp.SetBreak(&Break{Ready: b.Ready, Due: b.Due, Duration: b.Duration}); err != nil {
+			return nil, errorf("break", err)
+		}
+	}
+
+	if in.Fleet != nil {
+		if in.Vehicles != 0 || in.EndNode != nil {
+			return nil, errorf("fleet", fmt.Errorf(
+				"%w: fleet cannot be combined with vehicles and endNode", ErrFleet))
+		}
+
+		fleet := make([]Vehicle, len(in.Fleet))
+		for k, v := range in.Fleet {
+			fleet[k] = Vehicle{Capacity: v.Capacity, Start: v.Start, End: -1, Ready: v.Ready,
+				Due: v.Due, Carrying: v.Carrying, Cost: 1}
+			if v.End != nil {
+				fleet[k].End = *v.End
+			}
+			if v.Cost != nil {
+				fleet[k].Cost = *v.Cost
+			}
+		}
+		if err := tsp.SetFleet(fleet); err != nil {
+			return nil, errorf("fleet", err)
+		}
+	}
+
+	if err := tsp.checkPrecedence(); err != nil {
+		return nil, errorf("precedences", err)
+	}
+
+	for i, node := range in.Nodes {
+		if node.Rejection == 0 {
+			continue
+		}
+		if err := tsp.SetRejectionCost(node.ID, node.Rejection); err != nil {
+			return nil, errorf(fmt.Sprintf("nodes[%d].rejection", i), err)
+		}
+	}
+
+	switch {
+	case in.Matrix != nil:
+		if len(in.Matrix) != numNodes {
+			return nil, errorf("matrix", fmt.Errorf("%w: expected %d rows, got %d",
+				ErrMissingMatrix, numNodes, len(in.Matrix)))
+		}
+		for i, row := range in.Matrix {
+			if len(row) != numNodes {
+				return nil, errorf(fmt.Sprintf("matrix[%d]", i), fmt.Errorf(
+					"%w: expected %d columns, got %d", ErrDimension, numNodes, len(row)))
+			}
+			for j, value := range row {
+				if value < -maxTravel || value > maxTravel {
+					return nil, errorf(fmt.Sprintf("matrix[%d][%d]", i, j), fmt.Errorf(
+						"%w: %d", ErrTravelRange, value))
+				}
+			}
+		}
+		matrix, err := matrixFromRows(in.Matrix)
+		if err != nil {
+			return nil, errorf("matrix", err)
+		}
+		tsp.matrix = matrix
+		if hasCoords {
+			tsp.coords = coords
+		}
+	case hasCoords:
+		metric, err := NewMetric(in.Metric, in.Speed)
+		if err != nil {
+			return nil, errorf("metric", err)
+		}
+		if err := tsp.SetCoordinates(coords, metric); err != nil {
+			return nil, errorf("nodes", err)
+		}
+	default:
+		return nil, errorf("matrix", fmt.Errorf(
+			"%w: neither matrix nor coordinates of all nodes given", ErrMissingMatrix))
+	}
+
+	return tsp, nil
+}
+
+// WriteJSON writes the instance in the JSON format
+func (tsp *PDPTW) WriteJSON(w io.Writer) error {
+	out := jsonInstance{
+		Name:      tsp.name,
+		StartNode: tsp.startNode,
+		Capacity:  tsp.capacity,
+		Traveled:  tsp.traveled,
+		Nodes:     make([]jsonNode, tsp.numNodes),
+	}
+
+	if !tsp.carrying.isZero() {
+		out.Carrying = tsp.carrying
+	}
+
+	for i := 0; i < tsp.numNodes; i++ {
+		node := jsonNode{
+			ID:          i,
+			ReadyTime:   tsp.readyTime[i],
+			DueDate:     tsp.dueDate[i],
+			ServiceTime: tsp.serviceTime[i],
+			Demand:      tsp.demand(i),
+		}
+		if tsp.coords != nil {
+			x, y := tsp.coords[i].X, tsp.coords[i].Y
+			node.X, node.Y = &x, &y
+		}
+		if tsp.hasWindows(i) {
+			for _, w := range tsp.windows[i] {
+				node.Windows = append(node.Windows, jsonWindow{ReadyTime: w.Ready, DueDate: w.Due})
+			}
+		}
+		if costs, ok := tsp.softCosts[i]; ok {
+			node.Soft = &jsonSoftWindow{Earliness: costs.Earliness, Lateness: costs.Lateness}
+			for _, step := range costs.Steps {
+				node.Soft.Steps = append(node.Soft.Steps,
+					jsonLatenessStep{After: step.After, Lateness: step.Lateness})
+			}
+		}
+		node.Rejection = tsp.RejectionCost(i)
+		out.Nodes[i] = node
+	}
+
+	for _, pair := range tsp.pairs() {
+		out.Pairs = append(out.Pairs, jsonPair{Pickup: pair[0], Delivery: pair[1],
+			MaxRideTime: tsp.MaxRideTime(pair[1])})
+	}
+
+	if tsp.endNode >= 0 {
+		endNode := tsp.endNode
+		out.EndNode = &endNode
+	}
+
+	switch {
+	case tsp.fleet != nil:
+		out.EndNode = nil
+		for _, v := range tsp.fleet {
+			vehicle := jsonVehicle{Capacity: v.Capacity, Start: v.Start, Ready: v.Ready,
+				Due: v.Due}
+			if !v.Carrying.isZero() {
+				vehicle.Carrying = v.Carrying
+			}
+			if v.End >= 0 {
+				end := v.End
+				vehicle.End = &end
+			}
+			if v.Cost != 1 {
+				cost := v.Cost
+				vehicle.Cost = &cost
+			}
+			out.Fleet = append(out.Fleet, vehicle)
+		}
+	case tsp.vehicles > 1:
+		out.Vehicles = tsp.vehicles
+	}
+	out.Loading = tsp.loading
+	out.MaxDuration = tsp.maxDuration
+	if b := tsp.rest; b != nil {
+		out.Break = &jsonBreak{Ready: b.Ready, Due: b.Due, Duration: b.Duration}
+	}
+
+	for _, rule := range tsp.precedences(false) {
+		out.Precedences = append(out.Precedences, jsonPrecedence{Before: rule[0], After: rule[1]})
+	}
+
+	if name, speed, ok := metricName(tsp.metric); ok && tsp.coords != nil {
+		out.Metric = name
+		out.Speed = speed
+	} else {
+		out.Matrix = tsp.matrix.rows()
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(out)
+}
+
+// ParseJSONSolution reads solution of the given instance in the JSON format,
+// the schedule is recomputed from the route
+func ParseJSONSolution(tsp *PDPTW, r io.Reader) (*Solution, error) {
+	var in jsonSolution
+
+	if err := json.NewDecoder(r).Decode(&in); err != nil {
+		return nil, &ParseError{File: tsp.name, Err: err}
+	}
+
+	if len(in.Route) < tsp.requiredNodes() || len(in.Route) > tsp.numNodes {
+		return nil, &ParseError{File: tsp.name, Field: "route", Err: fmt.Errorf(
+			"%w: expected %d nodes, got %d", ErrDimension, tsp.numNodes, len(in.Route))}
+	}
+
+	for i, node := range in.Route {
+		if node < 0 || node >= tsp.numNodes {
+			return nil, &ParseError{File: tsp.name, Field: fmt.Sprintf("route[%d]", i),
+				Err: fmt.Errorf("%w: %d", ErrNodeRange, node)}
+		}
+	}
+
+	s := NewSolution(tsp, in.Route)
+	return &s, nil
+}
+
+// WriteJSON writes the route with its schedule in the JSON format
+func (s *Solution) WriteJSON(w io.Writer) error {
+	out := jsonSolution{
+		Instance:      s.tsp.name,
+		Route:         s.route,
+		Schedule:      s.Schedule(),
+		MakeSpan:      s.MakeSpan(),
+		Distance:      s.TotalDistance(),
+		WindowCost:    s.windowCost(),
+		Unserved:      s.Unserved(),
+		RejectionCost: s.rejectionCost(),
+		Feasible:      s.IsFeasible(),
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(out)
+}
+
+// WriteJSON writes the routes with their schedules in the JSON format
+func (p *Plan) WriteJSON(w io.Writer) error {
+	out := jsonPlan{
+		Instance:      p.tsp.name,
+		Routes:        make([]jsonSolution, len(p.routes)),
+		Vehicles:      p.Vehicles(),
+		MakeSpan:      p.MakeSpan(),
+		Distance:      p.TotalDistance(),
+		WindowCost:    p.windowCost(),
+		Unserved:      p.Unserved(),
+		RejectionCost: p.rejectionCost(),
+		Feasible:      p.IsFeasible(),
+	}
+
+	for i, s := range p.routes {
+		vehicle := s.vehicle
+		out.Routes[i] = jsonSolution{
+			Vehicle:    &vehicle,
+			Route:      s.route,
+			Schedule:   s.Schedule(),
+			MakeSpan:   s.MakeSpan(),
+			Distance:   s.TotalDistance(),
+			WindowCost: s.windowCost(),
+			Feasible:   s.IsFeasible(),
+		}
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(out)
+}
@@ -0,0 +1,57 @@
+package core
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// jsonRoundTrip returns the instance written in the JSON format and read back
+// after checking that it is written the same again
+func jsonRoundTrip(t *testing.T, tsp *PDPTW) *PDPTW {
+	var written bytes.Buffer
+	if err := tsp.WriteJSON(&written); err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := ParseJSON(tsp.Name(), bytes.NewReader(written.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var again bytes.Buffer
+	if err := parsed.WriteJSON(&again); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(written.Bytes(), again.Bytes()) {
+		t.Fatalf("written\n%s\nread back as\n%s", written.String(), again.String())
+	}
+	return parsed
+}
+
+func TestJSONRoundTrip(t *testing.T) {
+	tsp := parseTestInstance(t, testDirectives...)
+	parsed := jsonRoundTrip(t, tsp)
+
+	// the instance read back has the tasks and directives of the original
+	var want, got strings.Builder
+	if err := tsp.Write(&want); err != nil {
+		t.Fatal(err)
+	}
+	if err := parsed.Write(&got); err != nil {
+		t.Fatal(err)
+	}
+	if got.String() != want.String() {
+		t.Fatalf("read back\n%s\nwant\n%s", got.String(), want.String())
+	}
+}
+
+func TestJSONRoundTripCoordinates(t *testing.T) {
+	tsp := lineInstance(t)
+	parsed := jsonRoundTrip(t, tsp)
+
+	for i, point := range tsp.Coordinates() {
+		if parsed.Coordinates()[i] != point {
+			t.Fatalf("coordinates %v, want %v", parsed.Coordinates(), tsp.Coordinates())
+		}
+	}
+}
@@ -0,0 +1,294 @@
+package core
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// lilimNode is one line of the Li & Lim node list
+type lilimNode struct {
+	line     int
+	point    Point
+	demand   int
+	ready    int
+	due      int
+	service  int
+	pickup   int
+	delivery int
+}
+
+var lilimFields = []string{"id", "x", "y", "demand", "readyTime", "dueDate",
+	"serviceTime", "pickup", "delivery"}
+
+// ParseLiLim reads PDPTW benchmark of Li & Lim or Sartori & Buriol. The depot
+// (node 0) becomes the start node, the travel times are derived from the
+// coordinates by metric (RoundedEuclidean if nil) in the Li & Lim files and
+// taken from the EDGES section in the Sartori & Buriol ones. The number of
+// vehicles of Li & Lim becomes the fleet, Sartori & Buriol instances have a
+// single vehicle. The speed must be positive but is ignored, all published
+// instances use speed 1.
+func ParseLiLim(name string, r io.Reader, metric Metric) (*PDPTW, error) {
+	if metric == nil {
+		metric = RoundedEuclidean{}
+	}
+
+	p := lilimParser{lineParser: lineParser{name: name}, metric: metric}
+
+	if err := p.scan(r, p.parseLine); err != nil {
+		return nil, err
+	}
+
+	return p.build()
+}
+
+// sections of the Sartori & Buriol format
+const (
+	lilimHeader = iota
+	lilimNodes
+	lilimEdges
+	lilimEOF
+)
+
+type lilimParser struct {
+	lineParser
+	section  int
+	sartori  bool
+	size     int
+	vehicles int
+	capacity int
+	metric   Metric
+	nodes    map[int]*lilimNode
+	matrix   [][]int
+}
+
+func (p *lilimParser) parseLine(fields []string) error {
+	switch p.section {
+	case lilimHeader:
+		if strings.HasSuffix(fields[0], ":") || p.sartori {
+			p.sartori = true
+			return p.parseKeyword(fields)
+		}
+		return p.parseHeader(fields)
+	case lilimNodes:
+		if p.sartori && fields[0] == "EDGES" {
+			p.section = lilimEdges
+			return nil
+		}
+		return p.parseNode(fields)
+	case lilimEdges:
+		if fields[0] == "EOF" {
+			p.section = lilimEOF
+			return nil
+		}
+		return p.parseRow(fields)
+	default:
+		return p.errorf("", fmt.Errorf("%w after EOF", ErrTaskFormat))
+	}
+}
+
+// parseHeader reads "vehicles capacity speed" line of Li & Lim
+func (p *lilimParser) parseHeader(fields []string) error {
+	if len(fields) != 3 {
+		return p.errorf("header", fmt.Errorf("%w: expected 3 fields, got %d",
+			ErrHeader, len(fields)))
+	}
+
+	vehicles, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return p.errorf("vehicles", fmt.Errorf("%w %q", ErrSyntax, fields[0]))
+	}
+
+	capacity, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return p.errorf("capacity", fmt.Errorf("%w %q", ErrSyntax, fields[1]))
+	}
+
+	speed, err := p.float("speed", fields[2])
+	if err != nil {
+		return err
+	}
+
+	if speed <= 0 {
+		return p.errorf("speed", fmt.Errorf("%w: %v", ErrHeader, speed))
+	}
+
+	p.vehicles = vehicles
+	p.capacity = capacity
+	p.section = lilimNodes
+	return nil
+}
+
+// parseKeyword reads "KEY: value" lines of Sartori & Buriol
+func (p *lilimParser) parseKeyword(fields []string) error {
+	key := strings.TrimSuffix(fields[0], ":")
+
+	if key == "NODES" {
+		if p.size == 0 {
+			return p.errorf("SIZE", ErrHeader)
+		}
+		p.section = lilimNodes
+		return nil
+	}
+
+	if len(fields) < 2 {
+		return nil
+	}
+
+	var err error
+
+	switch key {
+	case "SIZE":
+		p.size, err = strconv.Atoi(fields[1])
+	case "CAPACITY":
+		p.capacity, err = strconv.Atoi(fields[1])
+	}
+
+	if err != nil {
+		return p.errorf(key, fmt.Errorf("%w %q", ErrSyntax, fields[1]))
+	}
+	return nil
+}
+
+func (p *lilimParser) parseNode(fields []string) error {
+	if len(fields) != len(lilimFields) {
+		return p.errorf("node", fmt.Errorf("%w: expected %d fields, got %d",
+			ErrTaskFormat, len(lilimFields), len(fields)))
+	}
+
+	id, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return p.errorf("id", fmt.Errorf("%w %q", ErrSyntax, fields[0]))
+	}
+
+	node := lilimNode{line: p.line}
+
+	if node.point.X, err = p.float("x", fields[1]); err != nil {
+		return err
+	}
+	if node.point.Y, err = p.float("y", fields[2]); err != nil {
+		return err
+	}
+
+	elems, err := p.ints(fields[3:], func(i int) string { return lilimFields[i+3] })
+	if err != nil {
+		return err
+	}
+
+	node.demand, node.ready, node.due, node.service = elems[0], elems[1], elems[2], elems[3]
+	node.pickup, node.delivery = elems[4], elems[5]
+
+	if p.nodes == nil {
+		p.nodes = make(map[int]*lilimNode)
+	}
+
+	if _, ok := p.nodes[id]; ok {
+		return p.errorf("id", fmt.Errorf("%w: %d", ErrDuplicateNode, id))
+	}
+
+	p.nodes[id] = &node
+	return nil
+}
+
+func (p *lilimParser) parseRow(fields []string) error {
+	elems, err := p.row(fields, len(p.matrix), p.size)
+	if err != nil {
+		return err
+	}
+
+	p.matrix = append(p.matrix, elems)
+	return nil
+}
+
+// nodeErrorf reports error at the line of the given node
+func (p *lilimParser) nodeErrorf(node *lilimNode, field string, err error) *ParseError {
+	return &ParseError{File: p.name, Line: node.line, Field: field, Err: err}
+}
+
+func (p *lilimParser) build() (*PDPTW, error) {
+	numNodes := len(p.nodes)
+
+	if numNodes == 0 {
+		return nil, &ParseError{File: p.name, Field: "node", Err: ErrMissingMatrix}
+	}
+
+	if p.sartori {
+		if numNodes != p.size {
+			return nil, &ParseError{File: p.name, Field: "SIZE", Err: fmt.Errorf(
+				"%w: expected %d nodes, got %d", ErrDimension, p.size, numNodes)}
+		}
+		if len(p.matrix) != p.size {
+			return nil, &ParseError{File: p.name, Line: p.line, Field: "EDGES", Err: fmt.Errorf(
+				"%w: expected %d rows, got %d", ErrMissingMatrix, p.size, len(p.matrix))}
+		}
+	}
+
+	tsp := newInstance(p.name, numNodes)
+	tsp.capacity = Load{p.capacity}
+
+	if p.vehicles != 0 {
+		if err := tsp.SetVehicles(p.vehicles); err != nil {
+			return nil, &ParseError{File: p.name, Field: "vehicles", Err: err}
+		}
+	}
+
+	for id := 0; id < numNodes; id++ {
+		node, ok := p.nodes[id]
+		if !ok {
+			return nil, &ParseError{File: p.name, Field: "id", Err: fmt.Errorf(
+				"%w: %d", ErrUndefinedNode, id)}
+		}
+
+		tsp.serviceTime[id] = node.service
+
+		switch {
+		case id == 0:
+			// depot
+			tsp.readyTime[id] = node.ready
+			tsp.dueDate[id] = node.due
+		case node.pickup == 0 && node.delivery == 0:
+			tsp.setSingle(id, Load{node.demand}, node.ready, node.due)
+		case node.pickup == 0:
+			delivery, ok := p.nodes[node.delivery]
+			if !ok || delivery.pickup != id {
+				return nil, p.nodeErrorf(node, "delivery", fmt.Errorf(
+					"%w: %d is not a delivery of %d", ErrTaskFormat, node.delivery, id))
+			}
+			if delivery.demand != -node.demand {
+				return nil, p.nodeErrorf(delivery, "demand", fmt.Errorf(
+					"%w: delivery demand %d does not cancel pickup demand %d",
+					ErrTaskFormat, delivery.demand, node.demand))
+			}
+			tsp.setPair(id, node.delivery, Load{node.demand}, node.ready, node.due,
+				delivery.ready, delivery.due)
+		case node.delivery == 0:
+			// set along with its pickup, only check the sibling exists
+			if pickup, ok := p.nodes[node.pickup]; !ok || pickup.delivery != id {
+				return nil, p.nodeErrorf(node, "pickup", fmt.Errorf(
+					"%w: %d is not a pickup of %d", ErrTaskFormat, node.pickup, id))
+			}
+		default:
+			return nil, p.nodeErrorf(node, "pickup", fmt.Errorf(
+				"%w: node %d is both pickup and delivery", ErrTaskFormat, id))
+		}
+	}
+
+	coords := make([]Point, numNodes)
+	for id, node := range p.nodes {
+		coords[id] = node.point
+	}
+
+	if p.sartori {
+		matrix, err := matrixFromRows(p.matrix)
+		if err != nil {
+			return nil, &ParseError{File: p.name, Field: "EDGES", Err: err}
+		}
+		tsp.coords = coords
+		tsp.matrix = matrix
+	} else if err := tsp.SetCoordinates(coords, p.metric); err != nil {
+		return nil, &ParseError{File: p.name, Field: "coordinates", Err: err}
+	}
+
+	return tsp, nil
+}
@@ -0,0 +1,160 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Load is an amount in every dimension of the vehicle capacity, e.g. weight
+// and volume. Nil is the empty load of any dimension.
+type Load []int
+
+// copy returns load which can be changed without changing l
+func (l Load) copy() Load {
+	x := make(Load, len(l))
+	copy(x, l)
+	return x
+}
+
+// add adds demand to the load in place
+func (l Load) add(demand Load) {
+	for i, amount := range demand {
+		l[i] += amount
+	}
+}
+
+// negated returns the load unloaded by a delivery of l
+func (l Load) negated() Load {
+	x := make(Load, len(l))
+	for i, amount := range l {
+		x[i] = -amount
+	}
+	return x
+}
+
+// exceeds returns whether the load is above capacity in some dimension
+func (l Load) exceeds(capacity Load) bool {
+	for i, amount := range l {
+		if amount > capacity[i] {
+			return true
+		}
+	}
+	return false
+}
+
+// exceedsWith returns whether the load with demand on board is above capacity
+// in some dimension
+func (l Load) exceedsWith(demand, capacity Load) bool {
+	for i, amount := range demand {
+		if l[i]+amount > capacity[i] {
+			return true
+		}
+	}
+	return false
+}
+
+// excess returns the sum of the amounts above capacity over all dimensions
+func (l Load) excess(capacity Load) (excess int) {
+	for i, amount := range l {
+		if amount > capacity[i] {
+			excess += amount - capacity[i]
+		}
+	}
+	return
+}
+
+// isZero returns whether nothing is loaded
+func (l Load) isZero() bool {
+	for _, amount := range l {
+		if amount != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// cancels returns whether unloading other empties the load l
+func (l Load) cancels(other Load) bool {
+	if len(l) != len(other) {
+		return l.isZero() && other.isZero()
+	}
+	for i, amount := range l {
+		if amount+other[i] != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// equal returns whether both loads have the same amounts
+func (l Load) equal(other Load) bool {
+	return l.cancels(other.negated())
+}
+
+// negative returns whether some amount is below zero
+func (l Load) negative() bool {
+	for _, amount := range l {
+		if amount < 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// positive returns whether some amount is loaded and none unloaded
+func (l Load) positive() bool {
+	for _, amount := range l {
+		if amount < 0 {
+			return false
+		}
+	}
+	return !l.isZero()
+}
+
+// String returns the amounts separated by commas as in the psa format
+func (l Load) String() string {
+	amounts := make([]string, len(l))
+	for i, amount := range l {
+		amounts[i] = strconv.Itoa(amount)
+	}
+	return strings.Join(amounts, ",")
+}
+
+// parseLoad reads amounts separated by commas
+func parseLoad(str string) (Load, error) {
+	fields := strings.Split(str, ",")
+	l := make(Load, len(fields))
+	for i, field := range fields {
+		amount, err := strconv.Atoi(field)
+		if err != nil {
+			return nil, fmt.Errorf("%w %q", ErrSyntax, str)
+		}
+		l[i] = amount
+	}
+	return l, nil
+}
+
+// MarshalJSON writes load of one dimension as a number, otherwise as an array
+func (l Load) MarshalJSON() ([]byte, error) {
+	if len(l) == 1 {
+		return json.Marshal(l[0])
+	}
+	return json.Marshal([]int(l))
+}
+
+// UnmarshalJSON reads a number as load of one dimension or an array
+func (l *Load) UnmarshalJSON(data []byte) error {
+	var amount int
+	if err := json.Unmarshal(data, &amount); err == nil {
+		*l = Load{amount}
+		return nil
+	}
+	var amounts []int
+	if err := json.Unmarshal(data, &amounts); err != nil {
+		return err
+	}
+	*l = amounts
+	return nil
+}
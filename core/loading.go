@@ -0,0 +1,168 @@
+package core
+
+import (
+	"fmt"
+)
+
+// Loading is the order in which the items of pickup and delivery pairs leave
+// the vehicle, nodes without partner are not items
+type Loading string
+
+// Loading orders accepted by SetLoading
+const (
+	// AnyOrder unloads the items in any order
+	AnyOrder Loading = ""
+	// LIFO unloads only the item loaded last, e.g. rear-loaded trucks
+	LIFO Loading = "lifo"
+	// FIFO unloads only the item loaded first
+	FIFO Loading = "fifo"
+)
+
+// SetLoading sets the order in which the items are unloaded
+func (tsp *PDPTW) SetLoading(loading Loading) error {
+	switch loading {
+	case AnyOrder, LIFO, FIFO:
+		tsp.loading = loading
+		return nil
+	default:
+		return fmt.Errorf("%w: unknown loading %q", ErrLoading, loading)
+	}
+}
+
+// Loading returns the order in which the items are unloaded
+func (tsp *PDPTW) Loading() Loading {
+	return tsp.loading
+}
+
+// hasRouteRules returns whether the instance has constraints evaluated on
+// the whole route only, they are checked after the moves of local search
+func (tsp *PDPTW) hasRouteRules() bool {
+	return len(tsp.maxRide) > 0 || tsp.loading != AnyOrder || tsp.rest != nil ||
+		tsp.maxDuration > 0
+}
+
+// meetsRouteRules returns whether the route keeps the ride times, the loading
+// order, the maximum duration and the break
+func (s *Solution) meetsRouteRules() bool {
+	return s.rideExcess() == 0 && s.loadingViolations() == 0 && s.shiftExcess() == 0
+}
+
+// loadingViolations returns the number of deliveries whose item is not the
+// next one to unload
+func (s *Solution) loadingViolations() (violations int) {
+	if s.tsp.loading == AnyOrder {
+		return 0
+	}
+
+	// items are kept by their deliveries
+	items := append([]int(nil), s.tsp.onboard...)
+	for _, node := range s.route {
+		if delivery := s.tsp.deliveryOf(node); delivery >= 0 {
+			items = append(items, delivery)
+			continue
+		}
+
+		k := -1
+		for i, item := range items {
+			if item == node {
+				k = i
+			}
+		}
+		if k < 0 {
+			continue
+		}
+
+		if s.tsp.loading == LIFO && k != len(items)-1 || s.tsp.loading == FIFO && k != 0 {
+			violations++
+		}
+		items = append(items[:k], items[k+1:]...)
+	}
+	return
+}
+
+// deliveryOf returns the delivery of the pickup, -1 if the node is not one
+func (tsp *PDPTW) deliveryOf(node int) int {
+	for _, next := range tsp.after[node] {
+		if pickup, ok := tsp.precedence[next]; ok && pickup == node {
+			return next
+		}
+	}
+	return -1
+}
+
+// blockEnd returns position of the delivery of the pickup at position i if
+// the pairs picked up between them are delivered in the reverse order before
+// it, -1 otherwise. Such block is loaded and unloaded as one item under LIFO.
+func (s *Solution) blockEnd(i int) int {
+	delivery := s.tsp.deliveryOf(s.route[i])
+	if delivery < 0 {
+		return -1
+	}
+
+	var open []int
+	for j := i + 1; j < len(s.route); j++ {
+		node := s.route[j]
+		switch {
+		case node == delivery:
+			if len(open) > 0 {
+				return -1
+			}
+			return j
+		case s.tsp.deliveryOf(node) >= 0:
+			open = append(open, s.tsp.deliveryOf(node))
+		default:
+			if pickup, ok := s.tsp.precedence[node]; ok && pickup >= 0 {
+				if len(open) == 0 || open[len(open)-1] != node {
+					return -1
+				}
+				open = open[:len(open)-1]
+			}
+		}
+	}
+	return -1
+}
+
+// passLoading returns the deliveries of the items loaded after the inserted
+// pickup once the node is served, false if the node unloads an item out of
+// the loading order of the pickup
+func (tsp *PDPTW) passLoading(open []int, node int) ([]int, bool) {
+	if tsp.loading == AnyOrder {
+		return open, true
+	}
+	if delivery := tsp.deliveryOf(node); delivery >= 0 {
+		return append(open, delivery), true
+	}
+	if tsp.isOnboard(node) {
+		// the items on board were loaded before the pickup
+		return open, tsp.loading != LIFO
+	}
+	if pickup, ok := tsp.precedence[node]; !ok || pickup < 0 {
+		return open, true
+	}
+
+	if tsp.loading == LIFO {
+		// only the item loaded last leaves, the pickup stays below
+		if len(open) == 0 || open[len(open)-1] != node {
+			return open, false
+		}
+		return open[:len(open)-1], true
+	}
+
+	// items loaded after the pickup leave after it
+	for _, delivery := range open {
+		if delivery == node {
+			return open, false
+		}
+	}
+	return open, true
+}
+
+// isOnboard returns whether the node delivers an item on board at the start
+func (tsp *PDPTW) isOnboard(node int) bool {
+	for _, delivery := range tsp.onboard {
+		if delivery == node {
+			return true
+		}
+	}
+	return false
+}
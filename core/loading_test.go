@@ -0,0 +1,40 @@
+package core
+
+import "testing"
+
+func TestLoadingViolations(t *testing.T) {
+	tests := []struct {
+		loading string
+		route   []int
+		// violations of the loading order
+		violations int
+	}{
+		{"lifo", []int{0, 1, 3, 4, 2}, 0},
+		{"lifo", []int{0, 1, 3, 2, 4}, 1},
+		{"fifo", []int{0, 1, 3, 2, 4}, 0},
+		{"fifo", []int{0, 1, 3, 4, 2}, 1},
+	}
+
+	for _, test := range tests {
+		tsp := parseTestInstance(t, "loading "+test.loading)
+		s := NewSolution(tsp, test.route)
+
+		if v := s.loadingViolations(); v != test.violations {
+			t.Errorf("%s %v: %d violations, want %d", test.loading, test.route, v,
+				test.violations)
+		}
+		if s.IsFeasible() != (test.violations == 0) {
+			t.Errorf("%s %v: feasible %v", test.loading, test.route, s.IsFeasible())
+		}
+	}
+}
+
+func TestProcessKeepsLIFO(t *testing.T) {
+	s, err := testCore().Process(parseTestInstance(t, "loading lifo"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !s.IsFeasible() || s.loadingViolations() != 0 {
+		t.Fatalf("route %v breaks the loading order", s.route)
+	}
+}
@@ -6,16 +6,16 @@ import (
 
 // constrained 2 opt
 type local2Opt struct {
-	traveled   []int
-	precedence map[int]int
-	carrying   map[int]int
+	traveled []int
+	carrying []Load
+	position []int
 	objective
 }
 
 func (c local2Opt) process(s *Solution) {
 	var pos, i int
 
-	numNodes := s.tsp.numNodes
+	numNodes := len(s.route)
 	// create auxiliary set
 	pointer := numNodes - 2
 	set := make([]int, pointer)
@@ -63,30 +63,29 @@ func (c local2Opt) exchangeGlobalUpdate(s *Solution, iaux, jaux int) {
 		// exchange
 		s.route[i], s.route[j] = s.route[j], s.route[i]
 
-		// update precedence
-		c.precedence[c.precedence[i]], c.precedence[c.precedence[j]],
-			c.precedence[j], c.precedence[i] = j, i, c.precedence[i], c.precedence[j]
+		// update positions
+		c.position[s.route[i]], c.position[s.route[j]] = i, j
 	}
 
 	var n1, n2 int
 
 	sum := c.traveled[iaux]
-	carrying := c.carrying[iaux-1]
+	// load before route[iaux]
+	carrying := s.tsp.vehicleAt(s.vehicle).Carrying.copy()
+	if iaux > 0 {
+		copy(carrying, c.carrying[iaux-1])
+	}
 
 	// update the reversed path
 	for i := iaux; i < jaux; i++ {
 		n1 = s.route[i]
 		n2 = s.route[i+1]
 
-		if s.tsp.readyTime[n1] > sum {
-			sum = s.tsp.readyTime[n1]
-		}
-
-		sum += s.tsp.matrix[n1][n2]
-		carrying += s.tsp.demands[n1]
+		sum = s.tsp.depart(n1, sum) + s.tsp.travel(n1, n2)
+		carrying.add(s.tsp.demands[n1])
 
 		c.traveled[i+1] = sum
-		c.carrying[i] = carrying
+		copy(c.carrying[i], carrying)
 	}
 
 	// update the rest
@@ -94,15 +93,11 @@ func (c local2Opt) exchangeGlobalUpdate(s *Solution, iaux, jaux int) {
 		n1 = s.route[i]
 		n2 = s.route[i+1]
 
-		if s.tsp.readyTime[n1] > sum {
-			sum = s.tsp.readyTime[n1]
-		}
-
-		sum += s.tsp.matrix[n1][n2]
-		carrying += s.tsp.demands[n1]
+		sum = s.tsp.depart(n1, sum) + s.tsp.travel(n1, n2)
+		carrying.add(s.tsp.demands[n1])
 
 		c.traveled[i+1] = sum
-		c.carrying[i] = carrying
+		copy(c.carrying[i], carrying)
 	}
 
 	return
@@ -112,9 +107,10 @@ func (c local2Opt) exchangeGlobalUpdate(s *Solution, iaux, jaux int) {
 func (c local2Opt) isFeasible(s *Solution, i, j int) bool {
 	var n1, n2 int
 	sum := c.traveled[i]
-	carrying := c.carrying[i]
+	carrying := c.carrying[i].copy()
+	capacity := s.capacity()
 
-	if !s.isFeasibleEdge(i, j, &sum, &carrying) {
+	if !s.isFeasibleEdge(i, j, &sum, carrying) {
 		return false
 	}
 
@@ -123,46 +119,60 @@ func (c local2Opt) isFeasible(s *Solution, i, j int) bool {
 		n1 = s.route[k]
 		n2 = s.route[k-1]
 
-		if s.tsp.readyTime[n1] > sum {
-			sum = s.tsp.readyTime[n1]
+		if !s.tsp.hasArc(n1, n2) {
+			return false
 		}
 
-		sum += s.tsp.matrix[n1][n2]
+		sum = s.tsp.depart(n1, sum) + s.tsp.travel(n1, n2)
 
-		if sum > s.tsp.dueDate[n2] {
+		if sum > s.tsp.due(n2) {
 			return false
 		}
 
-		// precendence
+		// precendence, predecessor within the reversed path
 
-		if c.precedence[k] > i && c.precedence[k] < j {
-			return false
+		for _, node := range s.tsp.before[n1] {
+			if c.position[node] > i && c.position[node] < k {
+				return false
+			}
 		}
 
 		// capacity
 
-		carrying += s.tsp.demands[n2]
+		carrying.add(s.tsp.demands[n2])
 
-		if carrying > s.tsp.capacity {
+		if carrying.exceeds(capacity) {
 			return false
 		}
 	}
 
 	if j+1 < len(s.route) {
-		if !s.isFeasibleEdge(i+1, j+1, &sum, &carrying) {
+		if !s.isFeasibleEdge(i+1, j+1, &sum, carrying) {
 			return false
 		}
 	}
 
-	if !s.isFeasibleRange(j+1, len(s.route)-1, &sum, &carrying) {
+	if !s.isFeasibleRange(j+1, len(s.route)-1, &sum, carrying) {
 		return false
 	}
 
-	return true
+	// ride times and loading order depend on both ends of the pairs, the
+	// route is evaluated
+	if s.tsp.hasRouteRules() {
+		x := s.Copy()
+		for a, b := i+1, j; a < b; a, b = a+1, b-1 {
+			x.route[a], x.route[b] = x.route[b], x.route[a]
+		}
+		if !x.meetsRouteRules() {
+			return false
+		}
+	}
+
+	return s.inShift(sum)
 }
 
-func (c *local2Opt) setGlobals(traveled []int, carrying, precedence map[int]int) {
+func (c *local2Opt) setGlobals(traveled []int, carrying []Load, position []int) {
 	c.traveled = traveled
-	c.precedence = precedence
 	c.carrying = carrying
+	c.position = position
 }
@@ -0,0 +1,47 @@
+package core
+
+// localBlocks moves blocks of a pickup with the pairs nested up to its
+// delivery, such block is one item in the stack of the vehicle
+type localBlocks struct {
+	objective objective
+}
+
+func (local localBlocks) process(x *Solution) {
+	for improved := true; improved; {
+		improved = false
+		for i := 1; i < x.movable(); i++ {
+			end := x.blockEnd(i)
+			if end < 0 {
+				continue
+			}
+			if y := local.move(x, i, end); y != nil {
+				copy(x.route, y.route)
+				improved = true
+			}
+		}
+	}
+}
+
+// move returns copy of the route with the block from i to end moved to the
+// feasible position with the lowest objective, nil if none improves it
+func (local localBlocks) move(x *Solution, i, end int) *Solution {
+	block := x.route[i : end+1]
+	rest := append(append([]int{}, x.route[:i]...), x.route[end+1:]...)
+
+	var best *Solution
+	bestCost := local.objective.get(x)
+
+	for k := 1; k <= x.movable()-len(block); k++ {
+		if k == i {
+			continue
+		}
+		route := make([]int, 0, len(x.route))
+		route = append(append(append(route, rest[:k]...), block...), rest[k:]...)
+
+		y := &Solution{route: route, tsp: x.tsp, vehicle: x.vehicle}
+		if c := local.objective.get(y); c < bestCost && y.IsFeasible() {
+			best, bestCost = y, c
+		}
+	}
+	return best
+}
@@ -19,9 +19,13 @@ func getLocalSearch(local config.LocalSearch, objective objective) localSearch {
 		return vnd{
 			objective:     objective,
 			local2Opt:     local2Opt,
-			localShifting: localShift}
+			localShifting: localShift,
+			localBlocks:   localBlocks{objective: objective},
+			localRequests: newLocalRequests(objective)}
 	case config.Shifting:
 		return localShift
+	case config.Blocks:
+		return localBlocks{objective: objective}
 	default:
 		return local2Opt
 	}
@@ -9,21 +9,24 @@ const (
 )
 
 type localshifting struct {
-	traveled   []int
-	precedence map[int]int
-	carrying   map[int]int
-	objective  objective
+	traveled  []int
+	carrying  []Load
+	position  []int
+	objective objective
 }
 
 func (local localshifting) process(x *Solution) {
 	local.setGlobals(x.calcGlobals())
 
 	for k := 0; k < iterMax; k++ {
-		i := utils.Random(1, len(x.route)-1)
+		i := utils.Random(1, x.movable()-1)
 
-		for j := 1; j < len(x.route); j++ {
+		for j := 1; j < x.movable(); j++ {
 			if i != j {
 				if local.isFeasible(x, i, j) == 0 {
+					if x.tsp.soft != nil && !local.improves(x, i, j) {
+						continue
+					}
 					local.shift(x, i, j)
 					break
 				}
@@ -33,87 +36,68 @@ func (local localshifting) process(x *Solution) {
 	return
 }
 
-func (c *localshifting) setGlobals(traveled []int, carrying, precedence map[int]int) {
+func (c *localshifting) setGlobals(traveled []int, carrying []Load, position []int) {
 	c.traveled = traveled
-	c.precedence = precedence
 	c.carrying = carrying
+	c.position = position
 }
 
 func (c *localshifting) updateGlobals(s *Solution, from int) {
 	var n1, n2 int
 
 	traveled := c.traveled[from-1]
-	carrying := c.carrying[from-1]
+	carrying := c.carrying[from-1].copy()
 
 	for i := from - 1; i < len(s.route)-1; i++ {
 		// traveled
 		n1 = s.route[i]
 		n2 = s.route[i+1]
 
-		if s.tsp.readyTime[n1] > traveled {
-			traveled = s.tsp.readyTime[n1]
-		}
-
-		traveled += s.tsp.matrix[n1][n2]
+		traveled = s.tsp.depart(n1, traveled) + s.tsp.travel(n1, n2)
 
 		c.traveled[i+1] = traveled
 
-		// precedence
-		if n, ok := s.tsp.precedence[n1]; ok {
-			index := utils.IndexOf(n, s.route)
-			c.precedence[index] = i
-			c.precedence[i] = index
-		} else if _, ok := c.precedence[i]; !ok {
-			// ignore precedence of vertex
-			c.precedence[i] = -1
-		}
+		// position
+		c.position[n2] = i + 1
 
-		carrying += s.tsp.demands[n2]
+		carrying.add(s.tsp.demands[n2])
 
-		c.carrying[i+1] = carrying
+		copy(c.carrying[i+1], carrying)
 	}
 
-	i := len(s.route) - 1
-
-	n := s.tsp.precedence[s.route[i]]
-
-	index := utils.IndexOf(n, s.route)
-	c.precedence[i] = index
-	c.precedence[index] = i
-
 	return
 }
 
 func (c localshifting) isFeasible(s *Solution, pos, newPos int) int {
-	var tail, traveled, carrying int
+	var tail, traveled int
+	var carrying Load
 
-	predPos := c.precedence[pos]
+	node := s.route[pos]
 
 	if newPos > pos {
 		// FORWARD
 		tail = newPos
 
-		// precedence
-		if predPos > pos {
-			// move of A
-			if newPos >= predPos {
+		// precedence, successor passed over
+		for _, next := range s.tsp.after[node] {
+			if c.position[next] > pos && c.position[next] <= newPos {
 				return -1
 			}
 		}
 
 		// time window and capacity
 		traveled = c.traveled[pos-1]
-		carrying = c.carrying[pos-1]
+		carrying = c.carrying[pos-1].copy()
 
-		if !s.isFeasibleEdge(pos-1, pos+1, &traveled, &carrying) {
+		if !s.isFeasibleEdge(pos-1, pos+1, &traveled, carrying) {
 			return -1
 		}
 
-		if !s.isFeasibleRange(pos+1, newPos, &traveled, &carrying) {
+		if !s.isFeasibleRange(pos+1, newPos, &traveled, carrying) {
 			return -1
 		}
 
-		if !s.isFeasibleEdge(newPos, pos, &traveled, &carrying) {
+		if !s.isFeasibleEdge(newPos, pos, &traveled, carrying) {
 			return -1
 		}
 
@@ -122,7 +106,7 @@ func (c localshifting) isFeasible(s *Solution, pos, newPos int) int {
 		}
 
 		if newPos+1 < len(s.route) {
-			if !s.isFeasibleEdge(pos, newPos+1, &traveled, &carrying) {
+			if !s.isFeasibleEdge(pos, newPos+1, &traveled, carrying) {
 				return -1
 			}
 
@@ -135,27 +119,26 @@ func (c localshifting) isFeasible(s *Solution, pos, newPos int) int {
 		// BACKWARD
 		tail = pos
 
-		// precedence
-		if predPos < pos {
-			// move of B
-			if newPos <= predPos {
+		// precedence, predecessor passed over
+		for _, prev := range s.tsp.before[node] {
+			if c.position[prev] >= newPos && c.position[prev] < pos {
 				return -1
 			}
 		}
 
 		// time window and capacity
 		traveled = c.traveled[newPos-1]
-		carrying = c.carrying[newPos-1]
+		carrying = c.carrying[newPos-1].copy()
 
-		if !s.isFeasibleEdge(newPos-1, pos, &traveled, &carrying) {
+		if !s.isFeasibleEdge(newPos-1, pos, &traveled, carrying) {
 			return -1
 		}
 
-		if !s.isFeasibleEdge(pos, newPos, &traveled, &carrying) {
+		if !s.isFeasibleEdge(pos, newPos, &traveled, carrying) {
 			return -1
 		}
 
-		if !s.isFeasibleRange(newPos, pos-1, &traveled, &carrying) {
+		if !s.isFeasibleRange(newPos, pos-1, &traveled, carrying) {
 			return -1
 		}
 
@@ -164,7 +147,7 @@ func (c localshifting) isFeasible(s *Solution, pos, newPos int) int {
 		}
 
 		if pos+1 < len(s.route) {
-			if !s.isFeasibleEdge(pos-1, pos+1, &traveled, &carrying) {
+			if !s.isFeasibleEdge(pos-1, pos+1, &traveled, carrying) {
 				return -1
 			}
 
@@ -174,13 +157,38 @@ func (c localshifting) isFeasible(s *Solution, pos, newPos int) int {
 		}
 	}
 
-	if !s.isFeasibleRange(tail+1, len(s.route)-1, &traveled, &carrying) {
+	if !s.isFeasibleRange(tail+1, len(s.route)-1, &traveled, carrying) {
 		return -1
 	}
 
+	// traveled is the arrival at the last node
+	if !s.inShift(traveled) {
+		return -1
+	}
+
+	// ride times and loading order depend on both ends of the pairs, the
+	// route is evaluated
+	if s.tsp.hasRouteRules() {
+		x := s.Copy()
+		x.exchange(pos, newPos)
+		if !x.meetsRouteRules() {
+			return -1
+		}
+	}
+
 	return 0
 }
 
+// improves returns whether the shift lowers the objective, without due dates
+// of soft windows nearly every shift is feasible and would scatter the route
+func (local localshifting) improves(x *Solution, pos, newPos int) bool {
+	y := x.Copy()
+	node := y.route[pos]
+	y.route = append(y.route[:pos], y.route[pos+1:]...)
+	y.route = append(y.route[:newPos], append([]int{node}, y.route[newPos:]...)...)
+	return local.objective.get(y) < local.objective.get(x)
+}
+
 func (local localshifting) shift(x *Solution, pos, newPos int) {
 	var from int
 	node := x.route[pos]
@@ -0,0 +1,73 @@
+package core
+
+import (
+	"fmt"
+	"math"
+)
+
+// maxTravel is the largest travel time stored in travelMatrix
+const maxTravel = math.MaxInt32
+
+// travelMatrix holds travel times of all arcs in a single row-major buffer,
+// which halves the memory of [][]int and saves a pointer hop per lookup
+type travelMatrix struct {
+	size int
+	data []int32
+}
+
+func newTravelMatrix(size int) travelMatrix {
+	return travelMatrix{size: size, data: make([]int32, size*size)}
+}
+
+// matrixFromRows copies square matrix into travelMatrix, returns error if a
+// row has other length or a value does not fit into maxTravel
+func matrixFromRows(rows [][]int) (travelMatrix, error) {
+	m := newTravelMatrix(len(rows))
+	for i, row := range rows {
+		if len(row) != len(rows) {
+			return travelMatrix{}, fmt.Errorf("%w: row %d has %d columns, expected %d",
+				ErrDimension, i, len(row), len(rows))
+		}
+		for j, value := range row {
+			if err := checkTravel(value); err != nil {
+				return travelMatrix{}, err
+			}
+			m.set(i, j, value)
+		}
+	}
+	return m, nil
+}
+
+// checkTravel returns error if the travel time does not fit into travelMatrix
+func checkTravel(value int) error {
+	if value < -maxTravel || value > maxTravel {
+		return fmt.Errorf("%w: %d", ErrTravelRange, value)
+	}
+	return nil
+}
+
+func (m travelMatrix) get(i, j int) int {
+	return int(m.data[i*m.size+j])
+}
+
+// set stores the travel time checked by checkTravel
+func (m travelMatrix) set(i, j, value int) {
+	m.data[i*m.size+j] = int32(value)
+}
+
+// rows returns copy of the matrix as slice of rows
+func (m travelMatrix) rows() [][]int {
+	rows := make([][]int, m.size)
+	for i := range rows {
+		rows[i] = make([]int, m.size)
+		for j := range rows[i] {
+			rows[i][j] = m.get(i, j)
+		}
+	}
+	return rows
+}
+
+// travel returns travel time from node i to node j
+func (tsp *PDPTW) travel(i, j int) int {
+	return int(tsp.matrix.data[i*tsp.matrix.size+j])
+}
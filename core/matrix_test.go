@@ -0,0 +1,84 @@
+package core
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/mitas1/psa-core/config"
+)
+
+// benchmarkPenalty are the construction weights of config.yaml
+var benchmarkPenalty = config.Penalty{TimeWindows: 100, PickupDelivery: 10, Capacity: 1}
+
+// benchmarkInstance returns the preprocessed hosny PDP_200 instance with a
+// random route of its pairs
+func benchmarkInstance(b *testing.B) (*PDPTW, *Solution) {
+	tsp, err := ParseInstanceFile("../_instances/hosny/PDP_200.txt.psa")
+	if err != nil {
+		b.Fatal(err)
+	}
+	tsp.Preprocess()
+	rand.Seed(1)
+	return tsp, GetRandomPD(tsp)
+}
+
+// benchmarkFeasible returns a feasible route of the benchmark instance built
+// by the construction
+func benchmarkFeasible(b *testing.B) *Solution {
+	tsp, _ := benchmarkInstance(b)
+	c := NewCons(config.Construction{LevelMax: 10, Penalty: benchmarkPenalty})
+	s := c.process(tsp)
+	if !s.IsFeasible() {
+		b.Fatal("construction found no feasible route")
+	}
+	return s
+}
+
+func BenchmarkPenalty(b *testing.B) {
+	_, s := benchmarkInstance(b)
+	c := NewCons(config.Construction{Penalty: benchmarkPenalty})
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		c.Penalty(s)
+	}
+}
+
+func BenchmarkSpanObjective(b *testing.B) {
+	_, s := benchmarkInstance(b)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		spanTime{}.get(s)
+	}
+}
+
+func BenchmarkProfitable2Opt(b *testing.B) {
+	_, s := benchmarkInstance(b)
+	traveled, _, _ := s.calcGlobals()
+	n := len(s.route)
+	b.ResetTimer()
+	for k := 0; k < b.N; k++ {
+		for i := 0; i < n-3; i += 7 {
+			for j := i + 2; j < n-1; j += 5 {
+				spanTime{}.isProfitable(s, i, j, traveled[j+1], traveled[i])
+			}
+		}
+	}
+}
+
+func BenchmarkFeasible(b *testing.B) {
+	s := benchmarkFeasible(b)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		s.IsFeasible()
+	}
+}
+
+func BenchmarkVND(b *testing.B) {
+	s := benchmarkFeasible(b)
+	search := getLocalSearch(config.VND, spanTime{})
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		rand.Seed(int64(i))
+		search.process(s.Copy())
+	}
+}
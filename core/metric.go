@@ -0,0 +1,137 @@
+package core
+
+import (
+	"fmt"
+	"math"
+)
+
+// Point is a location of a node. For the Haversine metric X is latitude and Y
+// is longitude in degrees.
+type Point struct {
+	X float64
+	Y float64
+}
+
+// Metric computes travel time between two points
+type Metric interface {
+	Distance(a, b Point) int
+}
+
+// Euclidean distance truncated to integer
+type Euclidean struct{}
+
+// RoundedEuclidean distance rounded to nearest integer (TSPLIB EUC_2D)
+type RoundedEuclidean struct{}
+
+// CeiledEuclidean distance rounded up (TSPLIB CEIL_2D)
+type CeiledEuclidean struct{}
+
+// Manhattan distance rounded to nearest integer
+type Manhattan struct{}
+
+// Haversine is the great-circle distance in kilometers divided by Speed
+// (kilometers per time unit), rounded to nearest integer
+type Haversine struct {
+	Speed float64
+}
+
+// Names of metrics accepted by NewMetric
+const (
+	EuclideanMetric        = "euclidean"
+	RoundedEuclideanMetric = "euc2d"
+	CeiledEuclideanMetric  = "ceil2d"
+	ManhattanMetric        = "manhattan"
+	HaversineMetric        = "haversine"
+)
+
+// earthRadius in kilometers
+const earthRadius = 6371.0
+
+// NewMetric returns metric of the given name, speed is used only by haversine
+func NewMetric(name string, speed float64) (Metric, error) {
+	switch name {
+	case EuclideanMetric:
+		return Euclidean{}, nil
+	case RoundedEuclideanMetric, "":
+		return RoundedEuclidean{}, nil
+	case CeiledEuclideanMetric:
+		return CeiledEuclidean{}, nil
+	case ManhattanMetric:
+		return Manhattan{}, nil
+	case HaversineMetric:
+		if speed <= 0 {
+			return nil, fmt.Errorf("haversine metric needs positive speed, got %v", speed)
+		}
+		return Haversine{Speed: speed}, nil
+	default:
+		return nil, fmt.Errorf("unknown metric %q", name)
+	}
+}
+
+func (Euclidean) Distance(a, b Point) int {
+	return int(math.Hypot(a.X-b.X, a.Y-b.Y))
+}
+
+func (RoundedEuclidean) Distance(a, b Point) int {
+	return int(math.Round(math.Hypot(a.X-b.X, a.Y-b.Y)))
+}
+
+func (CeiledEuclidean) Distance(a, b Point) int {
+	return int(math.Ceil(math.Hypot(a.X-b.X, a.Y-b.Y)))
+}
+
+func (Manhattan) Distance(a, b Point) int {
+	return int(math.Round(math.Abs(a.X-b.X) + math.Abs(a.Y-b.Y)))
+}
+
+func (h Haversine) Distance(a, b Point) int {
+	lat1 := a.X * math.Pi / 180
+	lat2 := b.X * math.Pi / 180
+	dLat := lat2 - lat1
+	dLon := (b.Y - a.Y) * math.Pi / 180
+
+	x := math.Pow(math.Sin(dLat/2), 2) +
+		math.Cos(lat1)*math.Cos(lat2)*math.Pow(math.Sin(dLon/2), 2)
+
+	km := 2 * earthRadius * math.Asin(math.Sqrt(x))
+
+	return int(math.Round(km / h.Speed))
+}
+
+// buildMatrix computes travel matrix between all the points, returns error
+// if a distance does not fit into the matrix
+func buildMatrix(coords []Point, metric Metric) (travelMatrix, error) {
+	matrix := newTravelMatrix(len(coords))
+
+	for i := range coords {
+		for j := range coords {
+			if i == j {
+				continue
+			}
+			distance := metric.Distance(coords[i], coords[j])
+			if err := checkTravel(distance); err != nil {
+				return travelMatrix{}, fmt.Errorf("points %d and %d: %w", i, j, err)
+			}
+			matrix.set(i, j, distance)
+		}
+	}
+	return matrix, nil
+}
+
+// metricName returns name and speed of the metric accepted by NewMetric
+func metricName(metric Metric) (name string, speed float64, ok bool) {
+	switch m := metric.(type) {
+	case Euclidean:
+		return EuclideanMetric, 0, true
+	case RoundedEuclidean:
+		return RoundedEuclideanMetric, 0, true
+	case CeiledEuclidean:
+		return CeiledEuclideanMetric, 0, true
+	case Manhattan:
+		return ManhattanMetric, 0, true
+	case Haversine:
+		return HaversineMetric, m.Speed, true
+	default:
+		return "", 0, false
+	}
+}
@@ -13,6 +13,10 @@ type spanTime struct{}
 type totalTime struct{}
 type totalTimeA struct{}
 
+// softTime is the makespan with the cost of the service outside of the soft
+// windows
+type softTime struct{}
+
 func NewObjective(opts config.Optimization) objective {
 	switch {
 	case "time" == opts.Objective && opts.Asymetric:
@@ -21,6 +25,8 @@ func NewObjective(opts config.Optimization) objective {
 		return totalTime{}
 	case "span" == opts.Objective:
 		return spanTime{}
+	case "soft" == opts.Objective:
+		return softTime{}
 	default:
 		return spanTime{}
 	}
@@ -29,12 +35,9 @@ func NewObjective(opts config.Optimization) objective {
 func (spanTime) get(s *Solution) int {
 	traveled := 0
 	for i := 0; i < len(s.route)-1; i++ {
-		if traveled < s.tsp.readyTime[s.route[i]] {
-			traveled = s.tsp.readyTime[s.route[i]]
-		}
-		traveled += s.tsp.matrix[s.route[i]][s.route[i+1]]
+		traveled = s.tsp.depart(s.route[i], traveled) + s.tsp.travel(s.route[i], s.route[i+1])
 	}
-	return traveled
+	return traveled + s.restDelay() + s.rejectionCost()
 }
 
 func (spanTime) isProfitable(s *Solution, i, j int, spans ...int) bool {
@@ -44,31 +47,19 @@ func (spanTime) isProfitable(s *Solution, i, j int, spans ...int) bool {
 	n1 = s.route[i]
 	n2 = s.route[j]
 
-	if s.tsp.readyTime[n1] > sum {
-		sum = s.tsp.readyTime[n1]
-	}
-
-	sum += s.tsp.matrix[n1][n2]
+	sum = s.tsp.depart(n1, sum) + s.tsp.travel(n1, n2)
 
 	for k := j; k > i+1; k-- {
 		n1 = s.route[k]
 		n2 = s.route[k-1]
 
-		if s.tsp.readyTime[n1] > sum {
-			sum = s.tsp.readyTime[n1]
-		}
-
-		sum += s.tsp.matrix[n1][n2]
+		sum = s.tsp.depart(n1, sum) + s.tsp.travel(n1, n2)
 	}
 
 	n1 = s.route[i+1]
 	n2 = s.route[j+1]
 
-	if s.tsp.readyTime[n1] > sum {
-		sum = s.tsp.readyTime[n1]
-	}
-
-	sum += s.tsp.matrix[n1][n2]
+	sum = s.tsp.depart(n1, sum) + s.tsp.travel(n1, n2)
 
 	return spans[0] > sum
 }
@@ -76,9 +67,9 @@ func (spanTime) isProfitable(s *Solution, i, j int, spans ...int) bool {
 func (totalTime) get(s *Solution) int {
 	traveled := 0
 	for i := 0; i < len(s.route)-1; i++ {
-		traveled += s.tsp.matrix[s.route[i]][s.route[i+1]]
+		traveled += s.tsp.travel(s.route[i], s.route[i+1])
 	}
-	return traveled
+	return traveled + s.rejectionCost()
 }
 
 func (totalTime) isProfitable(s *Solution, i, j int, spans ...int) bool {
@@ -87,15 +78,15 @@ func (totalTime) isProfitable(s *Solution, i, j int, spans ...int) bool {
 	n3 := s.route[j]
 	n4 := 0
 
-	if j < s.tsp.numNodes-1 {
+	if j < len(s.route)-1 {
 		n4 = s.route[j+1]
 	}
 
-	e1 := s.tsp.matrix[n1][n2]
-	e2 := s.tsp.matrix[n3][n4]
+	e1 := s.tsp.travel(n1, n2)
+	e2 := s.tsp.travel(n3, n4)
 
-	e3 := s.tsp.matrix[n1][n3]
-	e4 := s.tsp.matrix[n2][n4]
+	e3 := s.tsp.travel(n1, n3)
+	e4 := s.tsp.travel(n2, n4)
 
 	return e1+e2 > e3+e4
 }
@@ -103,9 +94,9 @@ func (totalTime) isProfitable(s *Solution, i, j int, spans ...int) bool {
 func (totalTimeA) get(s *Solution) int {
 	traveled := 0
 	for i := 0; i < len(s.route)-1; i++ {
-		traveled += s.tsp.matrix[s.route[i]][s.route[i+1]]
+		traveled += s.tsp.travel(s.route[i], s.route[i+1])
 	}
-	return traveled
+	return traveled + s.rejectionCost()
 }
 
 func (totalTimeA) isProfitable(s *Solution, i, j int, spans ...int) bool {
@@ -114,17 +105,131 @@ func (totalTimeA) isProfitable(s *Solution, i, j int, spans ...int) bool {
 	n3 := s.route[j]
 	n4 := 0
 
-	if j < s.tsp.numNodes-1 {
+	if j < len(s.route)-1 {
 		n4 = s.route[j+1]
 	}
 
-	e1 := s.tsp.matrix[n1][n2]
-	e2 := s.tsp.matrix[n3][n4]
+	e1 := s.tsp.travel(n1, n2)
+	e2 := s.tsp.travel(n3, n4)
 
-	e3 := s.tsp.matrix[n1][n3]
-	e4 := s.tsp.matrix[n2][n4]
+	e3 := s.tsp.travel(n1, n3)
+	e4 := s.tsp.travel(n2, n4)
 
 	// TODO handle asymetric
 
 	return e1+e2 > e3+e4
 }
+
+// softWindow returns the default costs of the soft windows, nil if the
+// windows of the objective are hard
+func softWindow(opts config.Optimization) *SoftWindow {
+	if opts.Objective != "soft" {
+		return nil
+	}
+
+	costs := SoftWindow{Earliness: opts.Soft.Earliness, Lateness: opts.Soft.Lateness}
+	for _, step := range opts.Soft.Steps {
+		costs.Steps = append(costs.Steps, LatenessStep{After: step.After, Lateness: step.Lateness})
+	}
+	return &costs
+}
+
+func (softTime) get(s *Solution) int {
+	return s.MakeSpan() + s.windowCost() + s.rejectionCost()
+}
+
+// isProfitable compares the route after i with the path from i+1 to j
+// reversed, a delay of one node changes the cost of all following ones until
+// both routes reach a node after j at the same time
+func (softTime) isProfitable(s *Solution, i, j int, spans ...int) bool {
+	var o2, n2 int
+
+	diff := 0
+	sum, old := spans[1], spans[1]
+	n1, o1 := s.route[i], s.route[i]
+
+	for k := i + 1; k < len(s.route); k++ {
+		o2, n2 = s.route[k], s.route[k]
+		if k <= j {
+			n2 = s.route[i+1+j-k]
+		}
+
+		sum = s.tsp.depart(n1, sum) + s.tsp.travel(n1, n2)
+		old = s.tsp.depart(o1, old) + s.tsp.travel(o1, o2)
+		diff += s.tsp.windowCost(n2, s.tsp.start(n2, sum)) - s.tsp.windowCost(o2, s.tsp.start(o2, old))
+
+		if k > j && sum == old {
+			break
+		}
+		n1, o1 = n2, o2
+	}
+	return diff+sum-old < 0
+}
+
+// planObjective weights the used vehicles, the total distance, the maximal
+// makespan and the cost of the route durations of a plan, the cost of soft
+// windows is added as it is
+type planObjective struct {
+	vehicles int
+	distance int
+	makeSpan int
+	cost     int
+}
+
+// newPlanObjective returns the weights of the config, without weights only
+// the makespan or the total distance is minimized by the objective
+func newPlanObjective(opts config.Optimization) planObjective {
+	switch {
+	case opts.Weights != (config.Weights{}):
+		return planObjective{
+			vehicles: opts.Weights.Vehicles,
+			distance: opts.Weights.Distance,
+			makeSpan: opts.Weights.MakeSpan,
+			cost:     opts.Weights.Cost}
+	case "time" == opts.Objective:
+		return planObjective{distance: 1}
+	default:
+		return planObjective{makeSpan: 1}
+	}
+}
+
+func (o planObjective) get(p *Plan) int {
+	cost := 0
+	for _, route := range p.routes {
+		v := p.tsp.vehicleAt(route.vehicle)
+		cost += v.Cost * (route.MakeSpan() - v.Ready)
+	}
+	return o.vehicles*p.Vehicles() + o.distance*p.TotalDistance() + o.makeSpan*p.MakeSpan() +
+		o.cost*cost + p.windowCost() + p.rejectionCost()
+}
+
+// route returns cost of a single route used to rank insertions, it also
+// breaks ties of plans with the same maximal makespan
+func (o planObjective) route(s *Solution) int {
+	return o.routeCost(s.tsp.vehicleAt(s.vehicle), s.TotalDistance(), s.MakeSpan()) +
+		s.windowCost()
+}
+
+func (o planObjective) routeCost(v Vehicle, distance, makeSpan int) int {
+	if o.distance == 0 && o.makeSpan == 0 && o.cost == 0 {
+		return distance
+	}
+	return o.distance*distance + o.makeSpan*makeSpan + o.cost*v.Cost*(makeSpan-v.Ready)
+}
+
+// better returns whether plan x has lower cost than p, ties are broken by
+// the sum of route costs
+func (o planObjective) better(x, p *Plan) bool {
+	if cx, cp := o.get(x), o.get(p); cx != cp {
+		return cx < cp
+	}
+
+	sum := 0
+	for _, route := range x.routes {
+		sum += o.route(route)
+	}
+	for _, route := range p.routes {
+		sum -= o.route(route)
+	}
+	return sum < 0
+}
@@ -0,0 +1,256 @@
+package core
+
+import (
+	"fmt"
+)
+
+// SetRejectionCost makes the request of the pickup or single node optional,
+// the solver may leave it unserved for the cost in the units of the
+// objective, e.g. the profit of the request. Zero makes the request required.
+// Optional requests must not be bound by precedence rules besides the pair.
+func (tsp *PDPTW) SetRejectionCost(node, cost int) error {
+	if node < 0 || node >= tsp.numNodes {
+		return fmt.Errorf("%w: %d", ErrNodeRange, node)
+	}
+	if cost < 0 {
+		return fmt.Errorf("%w: node %d has cost %d", ErrRejection, node, cost)
+	}
+	if pickup, ok := tsp.precedence[node]; ok && pickup >= 0 {
+		return fmt.Errorf("%w: %d is a delivery, the cost is set by its pickup %d", ErrRejection,
+			node, pickup)
+	}
+	if tsp.isDepot(node) {
+		return fmt.Errorf("%w: %d is a depot", ErrRejection, node)
+	}
+
+	nodes := tsp.requestOf(node)
+	for _, n := range nodes {
+		for _, others := range [][]int{tsp.before[n], tsp.after[n]} {
+			for _, other := range others {
+				if other != nodes[0] && other != nodes[len(nodes)-1] {
+					return fmt.Errorf("%w: request of %d is bound by precedence rules",
+						ErrRejection, node)
+				}
+			}
+		}
+	}
+
+	if cost == 0 {
+		delete(tsp.rejection, node)
+		return nil
+	}
+	if tsp.rejection == nil {
+		tsp.rejection = make(map[int]int)
+	}
+	tsp.rejection[node] = cost
+	return nil
+}
+
+// RejectionCost returns the cost of leaving the request of the pickup or
+// single node unserved, zero if it is required
+func (tsp *PDPTW) RejectionCost(node int) int {
+	return tsp.rejection[node]
+}
+
+// requestOf returns the node and its delivery if it is a pickup
+func (tsp *PDPTW) requestOf(node int) []int {
+	if delivery := tsp.deliveryOf(node); delivery >= 0 {
+		return []int{node, delivery}
+	}
+	return []int{node}
+}
+
+// isOptional returns whether the node belongs to an optional request
+func (tsp *PDPTW) isOptional(node int) bool {
+	if pickup, ok := tsp.precedence[node]; ok && pickup >= 0 {
+		node = pickup
+	}
+	_, ok := tsp.rejection[node]
+	return ok
+}
+
+// requiredNodes returns the number of nodes which every solution visits
+func (tsp *PDPTW) requiredNodes() int {
+	required := tsp.numNodes
+	for node := range tsp.rejection {
+		required -= len(tsp.requestOf(node))
+	}
+	return required
+}
+
+// Unserved returns the pickups and single nodes of the optional requests left
+// out of the route
+func (s *Solution) Unserved() (nodes []int) {
+	if len(s.tsp.rejection) == 0 {
+		return nil
+	}
+
+	served := make([]bool, s.tsp.numNodes)
+	for _, node := range s.route {
+		served[node] = true
+	}
+	for node := 0; node < s.tsp.numNodes; node++ {
+		if _, ok := s.tsp.rejection[node]; ok && !served[node] {
+			nodes = append(nodes, node)
+		}
+	}
+	return
+}
+
+// unservedNodes returns the number of nodes of the unserved requests
+func (s *Solution) unservedNodes() (count int) {
+	for _, node := range s.Unserved() {
+		count += len(s.tsp.requestOf(node))
+	}
+	return
+}
+
+// rejectionCost returns the sum of the costs of the unserved requests
+func (s *Solution) rejectionCost() (cost int) {
+	for _, node := range s.Unserved() {
+		cost += s.tsp.rejection[node]
+	}
+	return
+}
+
+// without returns copy of the route without the nodes
+func (s *Solution) without(nodes []int) *Solution {
+	route := make([]int, 0, len(s.route))
+outer:
+	for _, node := range s.route {
+		for _, removed := range nodes {
+			if node == removed {
+				continue outer
+			}
+		}
+		route = append(route, node)
+	}
+	return &Solution{route: route, tsp: s.tsp, vehicle: s.vehicle}
+}
+
+// insertRequest returns copy of the feasible route with the request of the
+// pickup or single node inserted at the positions with the least insertion
+// cost, nil if it fits nowhere
+func (s *Solution) insertRequest(node int, o planObjective) *Solution {
+	nodes := []int{node}
+	if delivery := s.tsp.deliveryOf(node); delivery >= 0 {
+		if x, ok := s.insertPair(node, delivery, 1, o); ok {
+			return x
+		}
+		nodes = append(nodes, delivery)
+	}
+	return s.insertNodes(nodes, 1, o)
+}
+
+// localRequests serves the unserved optional requests and rejects the served
+// ones while it lowers the objective with the rejection costs
+type localRequests struct {
+	objective objective
+	// insertion ranks the positions of the inserted requests
+	insertion planObjective
+}
+
+// newLocalRequests returns the search ranking insertions by the makespan or by
+// the distance for the objectives of the distance
+func newLocalRequests(objective objective) localRequests {
+	switch objective.(type) {
+	case totalTime, totalTimeA:
+		return localRequests{objective: objective, insertion: planObjective{distance: 1}}
+	default:
+		return localRequests{objective: objective, insertion: planObjective{makeSpan: 1}}
+	}
+}
+
+func (l localRequests) process(x *Solution) {
+	if len(x.tsp.rejection) == 0 {
+		return
+	}
+
+	for improved := true; improved; {
+		improved = false
+		cost := l.objective.get(x)
+
+		for _, node := range x.route {
+			if _, ok := x.tsp.rejection[node]; !ok {
+				continue
+			}
+			removed := x.without(x.tsp.requestOf(node))
+			if removed.IsFeasible() && l.objective.get(removed) < cost {
+				x.route, cost, improved = removed.route, l.objective.get(removed), true
+				break
+			}
+		}
+
+		for _, node := range x.Unserved() {
+			inserted := x.insertRequest(node, l.insertion)
+			if inserted != nil && l.objective.get(inserted) < cost {
+				x.route, cost, improved = inserted.route, l.objective.get(inserted), true
+			}
+		}
+	}
+}
+
+// initial returns the better of the routes improved from x and from x with
+// all optional requests inserted where they fit, single requests seldom pay
+// off on their own when they share the detours
+func (l localRequests) initial(x *Solution) *Solution {
+	if len(x.tsp.rejection) == 0 {
+		return x
+	}
+
+	all := x.Copy()
+	for _, node := range all.Unserved() {
+		if inserted := all.insertRequest(node, l.insertion); inserted != nil {
+			all.route = inserted.route
+		}
+	}
+
+	l.process(x)
+	l.process(all)
+	if l.objective.get(all) < l.objective.get(x) {
+		return all
+	}
+	return x
+}
+
+// isOptional returns whether the request may be left unserved
+func (p *Plan) isOptional(request int) bool {
+	return p.tsp.isOptional(p.requests[request][0])
+}
+
+// unserved returns the optional requests served by no route
+func (p *Plan) unserved() (requests []int) {
+	if len(p.tsp.rejection) == 0 {
+		return nil
+	}
+
+	served := make([]bool, len(p.requests))
+	for _, route := range p.routes {
+		for _, request := range p.requestsOf(route) {
+			served[request] = true
+		}
+	}
+	for request, ok := range served {
+		if !ok && p.isOptional(request) {
+			requests = append(requests, request)
+		}
+	}
+	return
+}
+
+// Unserved returns the pickups and single nodes of the optional requests
+// served by no route
+func (p *Plan) Unserved() (nodes []int) {
+	for _, request := range p.unserved() {
+		nodes = append(nodes, p.requests[request][0])
+	}
+	return
+}
+
+// rejectionCost returns the sum of the costs of the unserved requests
+func (p *Plan) rejectionCost() (cost int) {
+	for _, node := range p.Unserved() {
+		cost += p.tsp.rejection[node]
+	}
+	return
+}
@@ -0,0 +1,51 @@
+package core
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// farPSA is an instance of a near pair and a pair about 1000 units away
+const farPSA = `5 10 0
+0 10 20 1000 1010
+10 0 10 990 1000
+20 10 0 980 990
+1000 990 980 0 10
+1010 1000 990 10 0
+1 2 1 0 0 0 0
+3 4 1 0 0 0 0
+`
+
+func TestOptionalRequests(t *testing.T) {
+	tests := []struct {
+		cost     int
+		unserved []int
+	}{
+		// the far pair costs about 2000 more time units
+		{1, []int{3}},
+		{100000, nil},
+	}
+
+	for _, test := range tests {
+		tsp, err := ParseNamedInstance("far.psa", strings.NewReader(farPSA))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := tsp.SetRejectionCost(3, test.cost); err != nil {
+			t.Fatal(err)
+		}
+
+		s, err := testCore().Process(tsp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(s.Unserved(), test.unserved) {
+			t.Errorf("cost %d: route %v leaves %v unserved, want %v", test.cost, s.route,
+				s.Unserved(), test.unserved)
+		}
+		if !s.IsFeasible() {
+			t.Errorf("cost %d: route %v is infeasible", test.cost, s.route)
+		}
+	}
+}
@@ -0,0 +1,738 @@
+package core
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Errors wrapped by ParseError
+var (
+	ErrSyntax        = errors.New("invalid number")
+	ErrHeader        = errors.New("wrong header format")
+	ErrTaskFormat    = errors.New("wrong task format")
+	ErrDimension     = errors.New("dimension mismatch")
+	ErrNodeRange     = errors.New("node out of range")
+	ErrDuplicateNode = errors.New("node defined more than once")
+	ErrUndefinedNode = errors.New("node has no task line")
+	ErrMissingMatrix = errors.New("missing matrix rows")
+	ErrTravelRange   = errors.New("travel time out of range")
+	ErrPrecedence    = errors.New("invalid precedence")
+	ErrEndNode       = errors.New("invalid end node")
+	ErrFleet         = errors.New("invalid fleet")
+	ErrWindow        = errors.New("invalid time windows")
+	ErrRideTime      = errors.New("invalid ride time")
+	ErrLoading       = errors.New("invalid loading")
+	ErrExecuted      = errors.New("invalid executed prefix")
+	ErrRejection     = errors.New("invalid rejection cost")
+	ErrShift         = errors.New("invalid shift")
+)
+
+// ParseError describes a problem found while reading an instance. Line is
+// 1-based, zero when the problem is not bound to a single line.
+type ParseError struct {
+	File  string
+	Line  int
+	Field string
+	Err   error
+}
+
+func (e *ParseError) Error() string {
+	var b strings.Builder
+
+	if e.File != "" {
+		b.WriteString(e.File)
+		b.WriteString(":")
+	}
+	if e.Line > 0 {
+		fmt.Fprintf(&b, "%d:", e.Line)
+	}
+	if b.Len() > 0 {
+		b.WriteString(" ")
+	}
+	if e.Field != "" {
+		b.WriteString(e.Field)
+		b.WriteString(": ")
+	}
+	b.WriteString(e.Err.Error())
+	return b.String()
+}
+
+// Unwrap returns the underlying error
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// Format of an instance file
+type Format string
+
+// Supported instance formats
+const (
+	PSAFormat   Format = "psa"
+	LiLimFormat Format = "lilim"
+	JSONFormat  Format = "json"
+	HosnyFormat Format = "hosny"
+	// WanRongJihFormat of the original files in _instances/wan-rong-jih
+	WanRongJihFormat Format = "wan-rong-jih"
+	// TSPTWFormat is the matrix layout of TSPTW benchmarks
+	TSPTWFormat Format = "tsptw"
+	// TSPTWCoordsFormat is the Solomon like layout of TSPTW benchmarks
+	TSPTWCoordsFormat Format = "tsptw-coords"
+)
+
+// ParseFormat reads an instance stored in the given format, metric is used by
+// the formats with coordinates only
+func ParseFormat(format Format, name string, r io.Reader, metric Metric) (*PDPTW, error) {
+	switch format {
+	case PSAFormat, "":
+		return ParseNamedInstance(name, r)
+	case LiLimFormat:
+		return ParseLiLim(name, r, metric)
+	case JSONFormat:
+		return ParseJSON(name, r)
+	case HosnyFormat:
+		return ParseHosny(name, r, metric)
+	case WanRongJihFormat:
+		return ParseWanRongJih(name, r, metric)
+	case TSPTWFormat:
+		return ParseTSPTW(name, r)
+	case TSPTWCoordsFormat:
+		return ParseTSPTWCoords(name, r, metric)
+	default:
+		return nil, fmt.Errorf("unknown instance format %q", format)
+	}
+}
+
+// ParseInstance reads an instance in the .psa format
+func ParseInstance(r io.Reader) (*PDPTW, error) {
+	return ParseNamedInstance("instance", r)
+}
+
+// ParseInstanceFile reads an instance in the .psa format from the given file
+func ParseInstanceFile(filePath string) (*PDPTW, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	defer file.Close()
+
+	return ParseNamedInstance(path.Base(filePath), file)
+}
+
+// ParseNamedInstance reads an instance in the .psa format, name is used as the
+// instance name and in the reported errors
+func ParseNamedInstance(name string, r io.Reader) (*PDPTW, error) {
+	p := psaParser{lineParser: lineParser{name: name}, tsp: &PDPTW{name: name}}
+
+	if err := p.scan(r, p.parseLine); err != nil {
+		return nil, err
+	}
+
+	if err := p.finish(); err != nil {
+		return nil, err
+	}
+
+	return p.tsp, nil
+}
+
+// lineParser holds the position shared by the line based readers
+type lineParser struct {
+	name string
+	line int
+}
+
+// scan calls parse with the fields of every line which is neither blank nor a
+// comment
+func (p *lineParser) scan(r io.Reader, parse func([]string) error) error {
+	scanner := bufio.NewScanner(r)
+	// rows of large matrices do not fit the default buffer
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+
+	for scanner.Scan() {
+		p.line++
+
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' {
+			continue
+		}
+
+		if err := parse(strings.Fields(line)); err != nil {
+			return err
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return p.errorf("", err)
+	}
+	return nil
+}
+
+func (p *lineParser) errorf(field string, err error) *ParseError {
+	return &ParseError{File: p.name, Line: p.line, Field: field, Err: err}
+}
+
+func (p *lineParser) ints(fields []string, names func(int) string) ([]int, error) {
+	elems := make([]int, len(fields))
+	for i, str := range fields {
+		num, err := strconv.Atoi(str)
+		if err != nil {
+			return nil, p.errorf(names(i), fmt.Errorf("%w %q", ErrSyntax, str))
+		}
+		elems[i] = num
+	}
+	return elems, nil
+}
+
+// row reads matrix row with size travel times
+func (p *lineParser) row(fields []string, row, size int) ([]int, error) {
+	elems, err := p.ints(fields, func(i int) string {
+		return fmt.Sprintf("matrix[%d][%d]", row, i)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(elems) != size {
+		return nil, p.errorf(fmt.Sprintf("matrix[%d]", row), fmt.Errorf(
+			"%w: expected %d columns, got %d", ErrDimension, size, len(elems)))
+	}
+
+	for i, value := range elems {
+		if value < -maxTravel || value > maxTravel {
+			return nil, p.errorf(fmt.Sprintf("matrix[%d][%d]", row, i), fmt.Errorf(
+				"%w: %d", ErrTravelRange, value))
+		}
+	}
+	return elems, nil
+}
+
+func (p *lineParser) float(field, str string) (float64, error) {
+	num, err := strconv.ParseFloat(str, 64)
+	if err != nil {
+		return 0, p.errorf(field, fmt.Errorf("%w %q", ErrSyntax, str))
+	}
+	return num, nil
+}
+
+type psaParser struct {
+	lineParser
+	tsp     *PDPTW
+	header  bool
+	rows    [][]int
+	defined []bool
+	// fleet collects the vehicle lines, vehicles records the vehicles or end
+	// directive which cannot be combined with them
+	fleet    []Vehicle
+	vehicles string
+	// windows of nodes, ride times of pairs and rejection costs are set after
+	// their task lines
+	windows  map[int][]Window
+	rides    [][3]int
+	optional [][2]int
+}
+
+func (p *psaParser) parseLine(fields []string) error {
+	tsp := p.tsp
+
+	switch {
+	case !p.header:
+		return p.parseHeader(fields)
+	case len(p.rows) < tsp.numNodes:
+		return p.parseRow(fields)
+	case unicode.IsLetter(rune(fields[0][0])):
+		return p.parseDirective(fields)
+	default:
+		return p.parseTask(fields)
+	}
+}
+
+// loadFields reads fields like ints but the fields at positions are loads
+// with an amount per dimension separated by commas, they are returned in
+// order and left zero in elems. Loads of the tasks and vehicles must have the
+// dimensions of the capacity in the header.
+func (p *psaParser) loadFields(fields []string, names func(int) string, positions ...int) (
+	elems []int, loads []Load, err error) {
+	elems = make([]int, len(fields))
+
+outer:
+	for i, str := range fields {
+		for _, pos := range positions {
+			if i != pos {
+				continue
+			}
+			load, err := parseLoad(str)
+			if err != nil {
+				return nil, nil, p.errorf(names(i), err)
+			}
+			if p.header && len(load) != p.tsp.Dimensions() {
+				return nil, nil, p.errorf(names(i), fmt.Errorf("%w: expected %d amounts, got %d",
+					ErrDimension, p.tsp.Dimensions(), len(load)))
+			}
+			loads = append(loads, load)
+			continue outer
+		}
+
+		num, err := strconv.Atoi(str)
+		if err != nil {
+			return nil, nil, p.errorf(names(i), fmt.Errorf("%w %q", ErrSyntax, str))
+		}
+		elems[i] = num
+	}
+	return elems, loads, nil
+}
+
+var headerFields = []string{"numNodes", "capacity", "startNode", "traveled", "carrying"}
+
+func (p *psaParser) parseHeader(fields []string) error {
+	tsp := p.tsp
+
+	elems, loads, err := p.loadFields(fields, func(i int) string {
+		if i < len(headerFields) {
+			return headerFields[i]
+		}
+		return "header"
+	}, 1, 4)
+	if err != nil {
+		return err
+	}
+
+	if len(elems) != 3 && len(elems) != 5 {
+		return p.errorf("header", fmt.Errorf("%w: expected 3 or 5 fields, got %d",
+			ErrHeader, len(elems)))
+	}
+
+	if elems[0] <= 0 {
+		return p.errorf("numNodes", fmt.Errorf("%w: %d", ErrHeader, elems[0]))
+	}
+
+	// number of nodes
+	*tsp = *newInstance(tsp.name, elems[0])
+	// capacity of vehicle
+	if err := tsp.SetCapacity(loads[0]); err != nil {
+		return p.errorf("capacity", err)
+	}
+	// start node
+	tsp.startNode = elems[2]
+
+	if err := p.checkNode("startNode", tsp.startNode); err != nil {
+		return err
+	}
+
+	// init traveled and carrying if instance contains
+	if len(elems) > 3 {
+		tsp.traveled = elems[3]
+		if len(loads[1]) != tsp.Dimensions() {
+			return p.errorf("carrying", fmt.Errorf("%w: expected %d amounts, got %d",
+				ErrDimension, tsp.Dimensions(), len(loads[1])))
+		}
+		tsp.carrying = loads[1]
+	}
+
+	p.rows = make([][]int, 0, tsp.numNodes)
+
+	p.defined = make([]bool, tsp.numNodes)
+	p.header = true
+	return nil
+}
+
+func (p *psaParser) parseRow(fields []string) error {
+	elems, err := p.row(fields, len(p.rows), p.tsp.numNodes)
+	if err != nil {
+		return err
+	}
+
+	p.rows = append(p.rows, elems)
+	return nil
+}
+
+var vehicleFields = []string{"capacity", "start", "end", "ready", "due", "cost", "carrying"}
+
+var (
+	pairFields = []string{"pickup", "delivery", "demand", "pickupReady", "pickupDue",
+		"deliveryReady", "deliveryDue", "pickupService", "deliveryService"}
+	singleFields     = []string{"node", "demand", "readyTime", "dueDate", "serviceTime"}
+	precedenceFields = []string{"first", "second"}
+	rideFields       = []string{"pickup", "delivery", "limit"}
+	optionalFields   = []string{"node", "cost"}
+	breakFields      = []string{"ready", "due", "duration"}
+)
+
+// parseTask reads pair line with 7 fields or single node line with 4 fields,
+// both optionally followed by service times
+func (p *psaParser) parseTask(fields []string) error {
+	tsp := p.tsp
+
+	var names []string
+
+	switch len(fields) {
+	case len(pairFields), len(pairFields) - 2:
+		names = pairFields
+	case len(singleFields), len(singleFields) - 1:
+		names = singleFields
+	default:
+		return p.errorf("task", fmt.Errorf("%w: expected %d, %d, %d or %d fields, got %d",
+			ErrTaskFormat, len(singleFields)-1, len(singleFields), len(pairFields)-2,
+			len(pairFields), len(fields)))
+	}
+
+	// demand is the third field of pairs, the second of single nodes
+	demand := 1
+	if len(names) == len(pairFields) {
+		demand = 2
+	}
+	elems, loads, err := p.loadFields(fields, func(i int) string { return names[i] }, demand)
+	if err != nil {
+		return err
+	}
+
+	if len(elems) >= len(pairFields)-2 {
+		if err := p.define(names[0], elems[0]); err != nil {
+			return err
+		}
+		if err := p.define(names[1], elems[1]); err != nil {
+			return err
+		}
+		tsp.setPair(elems[0], elems[1], loads[0], elems[3], elems[4], elems[5], elems[6])
+
+		if len(elems) == len(pairFields) {
+			tsp.serviceTime[elems[0]] = elems[7]
+			tsp.serviceTime[elems[1]] = elems[8]
+		}
+	} else {
+		if err := p.define(names[0], elems[0]); err != nil {
+			return err
+		}
+		tsp.setSingle(elems[0], loads[0], elems[2], elems[3])
+
+		if len(elems) == len(singleFields) {
+			tsp.serviceTime[elems[0]] = elems[4]
+		}
+	}
+	return nil
+}
+
+// parseDirective reads keyword line following the matrix
+//
+//	precedence first second
+//	end node|open
+//	vehicles count
+//	vehicle capacity start end ready due [cost [carrying]]
+//	windows node ready due [ready due ...]
+//	soft node earliness lateness [after lateness ...]
+//	ride pickup delivery limit
+//	loading lifo|fifo
+//	optional node cost
+//	duration limit
+//	break ready due duration
+func (p *psaParser) parseDirective(fields []string) error {
+	switch fields[0] {
+	case "end", "vehicles":
+		if p.fleet != nil {
+			return p.errorf(fields[0], fmt.Errorf("%w: vehicle lines define the fleet", ErrFleet))
+		}
+		p.vehicles = fields[0]
+	case "vehicle":
+		if p.vehicles != "" {
+			return p.errorf("vehicle", fmt.Errorf("%w: %s directive defines the fleet",
+				ErrFleet, p.vehicles))
+		}
+	}
+
+	switch fields[0] {
+	case "vehicle":
+		if len(fields) < 6 || len(fields) > 8 {
+			return p.errorf("vehicle", fmt.Errorf(
+				"%w: expected \"vehicle capacity start end ready due [cost [carrying]]\"",
+				ErrTaskFormat))
+		}
+		elems, loads, err := p.loadFields(fields[1:],
+			func(i int) string { return vehicleFields[i] }, 0, 6)
+		if err != nil {
+			return err
+		}
+		if err := p.checkNode("start", elems[1]); err != nil {
+			return err
+		}
+		// open route ends anywhere
+		if elems[2] != -1 {
+			if err := p.checkNode("end", elems[2]); err != nil {
+				return err
+			}
+		}
+		v := Vehicle{Capacity: loads[0], Start: elems[1], End: elems[2], Ready: elems[3],
+			Due: elems[4], Cost: 1}
+		if len(elems) > 5 {
+			v.Cost = elems[5]
+		}
+		if len(elems) > 6 {
+			v.Carrying = loads[1]
+		}
+		p.fleet = append(p.fleet, v)
+	case "vehicles":
+		if len(fields) != 2 {
+			return p.errorf("vehicles", fmt.Errorf("%w: expected \"vehicles count\"", ErrTaskFormat))
+		}
+		elems, err := p.ints(fields[1:], func(int) string { return "vehicles" })
+		if err != nil {
+			return err
+		}
+		if err := p.tsp.SetVehicles(elems[0]); err != nil {
+			return p.errorf("vehicles", err)
+		}
+	case "loading":
+		if len(fields) != 2 {
+			return p.errorf("loading", fmt.Errorf("%w: expected \"loading lifo|fifo\"",
+				ErrTaskFormat))
+		}
+		if err := p.tsp.SetLoading(Loading(fields[1])); err != nil {
+			return p.errorf("loading", err)
+		}
+	case "duration":
+		if len(fields) != 2 {
+			return p.errorf("duration", fmt.Errorf("%w: expected \"duration limit\"",
+				ErrTaskFormat))
+		}
+		elems, err := p.ints(fields[1:], func(int) string { return "duration" })
+		if err != nil {
+			return err
+		}
+		if err := p.tsp.SetMaxDuration(elems[0]); err != nil {
+			return p.errorf("duration", err)
+		}
+	case "break":
+		if len(fields) != 4 {
+			return p.errorf("break", fmt.Errorf("%w: expected \"break ready due duration\"",
+				ErrTaskFormat))
+		}
+		elems, err := p.ints(fields[1:], func(i int) string { return breakFields[i] })
+		if err != nil {
+			return err
+		}
+		if err := p.tsp.SetBreak(&Break{Ready: elems[0], Due: elems[1], Duration: elems[2]}); err != nil {
+			return p.errorf("break", err)
+		}
+	case "end":
+		if len(fields) != 2 {
+			return p.errorf("end", fmt.Errorf("%w: expected \"end node\"", ErrTaskFormat))
+		}
+		if fields[1] == "open" {
+			p.tsp.endNode = -1
+			return nil
+		}
+		elems, err := p.ints(fields[1:], func(int) string { return "end" })
+		if err != nil {
+			return err
+		}
+		if err := p.checkNode("end", elems[0]); err != nil {
+			return err
+		}
+		if err := p.tsp.SetEndNode(elems[0]); err != nil {
+			return p.errorf("end", err)
+		}
+	case "windows":
+		if len(fields) < 4 || len(fields)%2 != 0 {
+			return p.errorf("windows", fmt.Errorf(
+				"%w: expected \"windows node ready due [ready due ...]\"", ErrTaskFormat))
+		}
+		elems, err := p.ints(fields[1:], func(i int) string {
+			if i == 0 {
+				return "node"
+			}
+			return fmt.Sprintf("windows[%d]", (i-1)/2)
+		})
+		if err != nil {
+			return err
+		}
+		if err := p.checkNode("node", elems[0]); err != nil {
+			return err
+		}
+		if _, ok := p.windows[elems[0]]; ok {
+			return p.errorf("node", fmt.Errorf("%w: %d has windows already", ErrDuplicateNode,
+				elems[0]))
+		}
+		var windows []Window
+		for i := 1; i < len(elems); i += 2 {
+			windows = append(windows, Window{Ready: elems[i], Due: elems[i+1]})
+		}
+		if p.windows == nil {
+			p.windows = make(map[int][]Window)
+		}
+		p.windows[elems[0]] = windows
+	case "soft":
+		if len(fields) < 4 || len(fields)%2 != 0 {
+			return p.errorf("soft", fmt.Errorf(
+				"%w: expected \"soft node earliness lateness [after lateness ...]\"", ErrTaskFormat))
+		}
+		elems, err := p.ints(fields[1:], func(i int) string {
+			switch i {
+			case 0:
+				return "node"
+			case 1:
+				return "earliness"
+			case 2:
+				return "lateness"
+			}
+			return fmt.Sprintf("steps[%d]", (i-3)/2)
+		})
+		if err != nil {
+			return err
+		}
+		if err := p.checkNode("node", elems[0]); err != nil {
+			return err
+		}
+		if _, ok := p.tsp.softCosts[elems[0]]; ok {
+			return p.errorf("node", fmt.Errorf("%w: %d has soft window already", ErrDuplicateNode,
+				elems[0]))
+		}
+		costs := SoftWindow{Earliness: elems[1], Lateness: elems[2]}
+		for i := 3; i < len(elems); i += 2 {
+			costs.Steps = append(costs.Steps, LatenessStep{After: elems[i], Lateness: elems[i+1]})
+		}
+		if err := p.tsp.SetSoftWindow(elems[0], costs); err != nil {
+			return p.errorf("soft", err)
+		}
+	case "precedence":
+		if len(fields) != 3 {
+			return p.errorf("precedence", fmt.Errorf("%w: expected \"precedence first second\"",
+				ErrTaskFormat))
+		}
+		elems, err := p.ints(fields[1:], func(i int) string { return precedenceFields[i] })
+		if err != nil {
+			return err
+		}
+		for i, node := range elems {
+			if err := p.checkNode(precedenceFields[i], node); err != nil {
+				return err
+			}
+		}
+		p.tsp.addPrecedence(elems[0], elems[1])
+	case "ride":
+		if len(fields) != 4 {
+			return p.errorf("ride", fmt.Errorf("%w: expected \"ride pickup delivery limit\"",
+				ErrTaskFormat))
+		}
+		elems, err := p.ints(fields[1:], func(i int) string { return rideFields[i] })
+		if err != nil {
+			return err
+		}
+		for i, node := range elems[:2] {
+			if err := p.checkNode(rideFields[i], node); err != nil {
+				return err
+			}
+		}
+		for _, ride := range p.rides {
+			if ride[1] == elems[1] {
+				return p.errorf("delivery", fmt.Errorf("%w: %d has ride time already",
+					ErrDuplicateNode, elems[1]))
+			}
+		}
+		p.rides = append(p.rides, [3]int{elems[0], elems[1], elems[2]})
+	case "optional":
+		if len(fields) != 3 {
+			return p.errorf("optional", fmt.Errorf("%w: expected \"optional node cost\"",
+				ErrTaskFormat))
+		}
+		elems, err := p.ints(fields[1:], func(i int) string { return optionalFields[i] })
+		if err != nil {
+			return err
+		}
+		if err := p.checkNode("node", elems[0]); err != nil {
+			return err
+		}
+		for _, optional := range p.optional {
+			if optional[0] == elems[0] {
+				return p.errorf("node", fmt.Errorf("%w: %d has rejection cost already",
+					ErrDuplicateNode, elems[0]))
+			}
+		}
+		p.optional = append(p.optional, [2]int{elems[0], elems[1]})
+	default:
+		return p.errorf(fields[0], fmt.Errorf("%w: unknown directive %q", ErrTaskFormat, fields[0]))
+	}
+	return nil
+}
+
+func (p *psaParser) checkNode(field string, node int) error {
+	if node < 0 || node >= p.tsp.numNodes {
+		return p.errorf(field, fmt.Errorf("%w: %d not in [0, %d)",
+			ErrNodeRange, node, p.tsp.numNodes))
+	}
+	return nil
+}
+
+func (p *psaParser) define(field string, node int) error {
+	if err := p.checkNode(field, node); err != nil {
+		return err
+	}
+	if p.defined[node] {
+		return p.errorf(field, fmt.Errorf("%w: %d", ErrDuplicateNode, node))
+	}
+	p.defined[node] = true
+	return nil
+}
+
+// finish validates the header counts against the lines read
+func (p *psaParser) finish() error {
+	tsp := p.tsp
+
+	if !p.header {
+		return &ParseError{File: p.name, Field: "header", Err: ErrHeader}
+	}
+
+	if len(p.rows) != tsp.numNodes {
+		return &ParseError{File: p.name, Line: p.line, Field: "matrix", Err: fmt.Errorf(
+			"%w: expected %d rows, got %d", ErrMissingMatrix, tsp.numNodes, len(p.rows))}
+	}
+
+	matrix, err := matrixFromRows(p.rows)
+	if err != nil {
+		return &ParseError{File: p.name, Field: "matrix", Err: err}
+	}
+	tsp.matrix = matrix
+
+	for node, windows := range p.windows {
+		if err := tsp.SetWindows(node, windows); err != nil {
+			return &ParseError{File: p.name, Field: "windows", Err: err}
+		}
+	}
+
+	for _, ride := range p.rides {
+		if err := tsp.SetMaxRideTime(ride[0], ride[1], ride[2]); err != nil {
+			return &ParseError{File: p.name, Field: "ride", Err: err}
+		}
+	}
+
+	if err := tsp.SetFleet(p.fleet); err != nil {
+		// the fleet checks the precedence rules against its depots
+		field := "vehicle"
+		if errors.Is(err, ErrPrecedence) {
+			field = "precedence"
+		}
+		return &ParseError{File: p.name, Field: field, Err: err}
+	}
+
+	if err := tsp.checkPrecedence(); err != nil {
+		return &ParseError{File: p.name, Field: "precedence", Err: err}
+	}
+
+	for _, optional := range p.optional {
+		if err := tsp.SetRejectionCost(optional[0], optional[1]); err != nil {
+			return &ParseError{File: p.name, Field: "optional", Err: err}
+		}
+	}
+
+	for node, ok := range p.defined {
+		if !ok && !tsp.isStart(node) {
+			return &ParseError{File: p.name, Field: "task", Err: fmt.Errorf(
+				"%w: %d", ErrUndefinedNode, node)}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package core
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// testPSA is an instance of two pairs on a line, directives added to it start
+// at line 9
+const testPSA = `5 10 0
+0 10 20 15 25
+10 0 10 5 15
+20 10 0 5 5
+15 5 5 0 10
+25 15 5 10 0
+1 2 1 0 100 0 100
+3 4 1 0 100 0 100
+`
+
+func TestParseDirectiveErrors(t *testing.T) {
+	tests := []struct {
+		lines []string
+		line  int
+		field string
+		err   error
+	}{
+		{[]string{"loading lilo"}, 9, "loading", ErrLoading},
+		{[]string{"duration long"}, 9, "duration", ErrSyntax},
+		{[]string{"break 10 20"}, 9, "break", ErrTaskFormat},
+		{[]string{"windows 7 0 10"}, 9, "node", ErrNodeRange},
+		{[]string{"ride 1 2 30", "ride 3 2 30"}, 10, "delivery", ErrDuplicateNode},
+		{[]string{"vehicles 2", "vehicle 10 0 -1 0 100"}, 10, "vehicle", ErrFleet},
+		// comments and blank lines are counted
+		{[]string{"# comment", "", "teleport 1"}, 11, "teleport", ErrTaskFormat},
+	}
+
+	for _, test := range tests {
+		text := testPSA + strings.Join(test.lines, "\n")
+		_, err := ParseNamedInstance("test.psa", strings.NewReader(text))
+
+		var parseErr *ParseError
+		if !errors.As(err, &parseErr) {
+			t.Errorf("%q: got %v, want ParseError", test.lines, err)
+			continue
+		}
+		if parseErr.Line != test.line || parseErr.Field != test.field ||
+			!errors.Is(err, test.err) {
+			t.Errorf("%q: got %v, want line %d, field %s and %v", test.lines, err, test.line,
+				test.field, test.err)
+		}
+	}
+}
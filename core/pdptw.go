@@ -1,22 +1,20 @@
 package core
 
 import (
-	"bufio"
 	"fmt"
 	"os"
 	"path"
-	"strconv"
-	"strings"
-
-	"github.com/mitas1/psa-core/utils"
+	"sort"
 )
 
 type TSP interface {
 	ReadFromFile(_path string, name string) interface{}
 	Print()
-	preprocess()
+	Preprocess() PreprocessReport
 }
 
+// CreateInstance returns instance with the given square matrix, it panics on
+// the errors reported by NewInstance
 func CreateInstance(
 	startNode int,
 	vehicleCapacity int,
@@ -28,40 +26,239 @@ func CreateInstance(
 	precedence map[int]int,
 	matrix [][]int,
 ) PDPTW {
-	return PDPTW{
+	tsp, err := NewInstance(startNode, vehicleCapacity, traveled, carrying, readyTime, dueDate,
+		demands, precedence, matrix)
+	if err != nil {
+		panic(err)
+	}
+	return tsp
+}
+
+// NewInstance returns instance with the given square matrix, returns error if
+// the matrix or the due dates do not match the ready times or a travel time
+// does not fit into int32. A nil matrix is left to SetCoordinates.
+func NewInstance(
+	startNode int,
+	vehicleCapacity int,
+	traveled int,
+	carrying int,
+	readyTime []int,
+	dueDate []int,
+	demands map[int]int,
+	precedence map[int]int,
+	matrix [][]int,
+) (PDPTW, error) {
+	if len(dueDate) != len(readyTime) || matrix != nil && len(matrix) != len(readyTime) {
+		return PDPTW{}, fmt.Errorf("%w: %d ready times, %d due dates and %d matrix rows",
+			ErrDimension, len(readyTime), len(dueDate), len(matrix))
+	}
+	travel, err := matrixFromRows(matrix)
+	if err != nil {
+		return PDPTW{}, err
+	}
+
+	tsp := PDPTW{
 		name:       "instance",
 		startNode:  startNode,
-		capacity:   vehicleCapacity,
+		endNode:    -1,
+		vehicles:   1,
+		capacity:   Load{vehicleCapacity},
 		traveled:   traveled,
-		carrying:   carrying,
-		numNodes:   len(matrix),
+		carrying:   Load{carrying},
+		numNodes:   len(readyTime),
 		readyTime:  readyTime,
 		dueDate:    dueDate,
-		demands:    demands,
+		demands:    make(map[int]Load, len(demands)),
 		precedence: precedence,
-		matrix:     matrix,
+		matrix:     travel,
+		// no service at nodes, use SetServiceTimes
+		serviceTime: make([]int, len(readyTime)),
+		before:      make([][]int, len(readyTime)),
+		after:       make([][]int, len(readyTime)),
+	}
+
+	for node, demand := range demands {
+		tsp.demands[node] = Load{demand}
 	}
+
+	for delivery, pickup := range precedence {
+		if pickup >= 0 {
+			tsp.addPrecedence(pickup, delivery)
+		}
+	}
+	return tsp, nil
+}
+
+// CreateInstanceFromCoordinates returns instance with matrix computed from
+// coordinates of nodes using the given metric, it panics on the errors
+// reported by NewInstanceFromCoordinates
+func CreateInstanceFromCoordinates(
+	startNode int,
+	vehicleCapacity int,
+	traveled int,
+	carrying int,
+	readyTime []int,
+	dueDate []int,
+	demands map[int]int,
+	precedence map[int]int,
+	coords []Point,
+	metric Metric,
+) PDPTW {
+	tsp, err := NewInstanceFromCoordinates(startNode, vehicleCapacity, traveled, carrying,
+		readyTime, dueDate, demands, precedence, coords, metric)
+	if err != nil {
+		panic(err)
+	}
+	return tsp
+}
+
+// NewInstanceFromCoordinates returns instance with matrix computed from
+// coordinates of nodes using the given metric, returns error if the
+// coordinates do not match the ready times or a distance does not fit into
+// int32
+func NewInstanceFromCoordinates(
+	startNode int,
+	vehicleCapacity int,
+	traveled int,
+	carrying int,
+	readyTime []int,
+	dueDate []int,
+	demands map[int]int,
+	precedence map[int]int,
+	coords []Point,
+	metric Metric,
+) (PDPTW, error) {
+	tsp, err := NewInstance(startNode, vehicleCapacity, traveled, carrying, readyTime,
+		dueDate, demands, precedence, nil)
+	if err != nil {
+		return PDPTW{}, err
+	}
+	if err := tsp.SetCoordinates(coords, metric); err != nil {
+		return PDPTW{}, err
+	}
+	return tsp, nil
 }
 
 type PDPTW struct {
-	name       string
-	startNode  int
-	capacity   int
-	numNodes   int
-	traveled   int
-	carrying   int
-	matrix     [][]int
-	readyTime  []int
-	dueDate    []int
-	demands    map[int]int
-	precedence map[int]int
-	pred       map[int]int
-	arcs       map[int]map[int]bool
-}
-
-// ReadFromFile reads the given tsptw instance from file
+	name      string
+	startNode int
+	// endNode is the last node of every route, -1 if the route is open
+	endNode int
+	// vehicles is the size of the fleet of vehicles identical to the one given
+	// by the scalars, fleet lists the vehicles if they differ
+	vehicles int
+	fleet    []Vehicle
+	// capacity, carrying and demands have the same number of dimensions
+	capacity Load
+	numNodes int
+	traveled int
+	carrying Load
+	matrix   travelMatrix
+	coords   []Point
+	metric   Metric
+	// readyTime and dueDate enclose the windows of nodes with several ones,
+	// soft keeps the windows and their costs when they are soft
+	readyTime   []int
+	dueDate     []int
+	windows     [][]Window
+	soft        *softWindows
+	softCosts   map[int]SoftWindow
+	serviceTime []int
+	demands     map[int]Load
+	precedence  map[int]int
+	// maxRide limits the ride time of pairs by their deliveries, loading is
+	// the order of unloading the pairs
+	maxRide map[int]int
+	loading Loading
+	// onboard are the deliveries of the items on board at the start in the
+	// order of loading, their pickups are not part of the instance
+	onboard []int
+	// maxDuration limits the duration of every route, rest is the break of
+	// the drivers
+	maxDuration int
+	rest        *Break
+	// rejection are the costs of leaving the optional requests unserved by
+	// their pickups or single nodes
+	rejection map[int]int
+	// before and after list nodes which must be visited before and after the
+	// node, including the pickup and delivery pairs
+	before [][]int
+	after  [][]int
+	arcs   [][]bool
+	// origin is the instance the copy solved by Core was made from, its
+	// windows are not tightened
+	origin *PDPTW
+}
+
+// newInstance returns an empty instance with allocated node data
+func newInstance(name string, numNodes int) *PDPTW {
+	return &PDPTW{
+		name:        name,
+		endNode:     -1,
+		vehicles:    1,
+		capacity:    Load{0},
+		carrying:    Load{0},
+		numNodes:    numNodes,
+		readyTime:   make([]int, numNodes),
+		dueDate:     make([]int, numNodes),
+		serviceTime: make([]int, numNodes),
+		demands:     make(map[int]Load),
+		precedence:  make(map[int]int),
+		before:      make([][]int, numNodes),
+		after:       make([][]int, numNodes),
+	}
+}
+
+// clone returns copy of the instance whose windows, node data and rules may
+// change without touching tsp
+func (tsp *PDPTW) clone() *PDPTW {
+	c := *tsp
+	c.readyTime = append([]int(nil), tsp.readyTime...)
+	c.dueDate = append([]int(nil), tsp.dueDate...)
+	c.serviceTime = append([]int(nil), tsp.serviceTime...)
+	if tsp.windows != nil {
+		c.windows = append([][]Window(nil), tsp.windows...)
+	}
+	c.demands = make(map[int]Load, len(tsp.demands))
+	for node, demand := range tsp.demands {
+		c.demands[node] = demand
+	}
+	c.precedence = copyMap(tsp.precedence)
+	c.maxRide = copyMap(tsp.maxRide)
+	c.rejection = copyMap(tsp.rejection)
+	c.before = make([][]int, len(tsp.before))
+	c.after = make([][]int, len(tsp.after))
+	for node := range tsp.before {
+		c.before[node] = append([]int(nil), tsp.before[node]...)
+		c.after[node] = append([]int(nil), tsp.after[node]...)
+	}
+	return &c
+}
+
+// copyMap returns copy of the map, nil if it is nil
+func copyMap(m map[int]int) map[int]int {
+	if m == nil {
+		return nil
+	}
+	c := make(map[int]int, len(m))
+	for key, value := range m {
+		c[key] = value
+	}
+	return c
+}
+
+// original returns the instance the copy solved by Core was made from, tsp
+// itself if it is not such copy
+func (tsp *PDPTW) original() *PDPTW {
+	if tsp.origin != nil {
+		return tsp.origin
+	}
+	return tsp
+}
+
+// ReadFromFile reads the given tsptw instance from file, any problem with the
+// file is fatal, use ParseInstance to handle errors
 func ReadFromFile(_path string, name string) *PDPTW {
-	tsp := PDPTW{}
 	file, err := os.Open(path.Join(_path, name))
 
 	if err != nil {
@@ -70,96 +267,297 @@ func ReadFromFile(_path string, name string) *PDPTW {
 
 	defer file.Close()
 
-	tsp.name = name
-
-	scanner := bufio.NewScanner(file)
-	var line string
-	i := 0
-
-	tsp.matrix = make([][]int, 0)
-	tsp.precedence = make(map[int]int)
-	tsp.pred = make(map[int]int)
-	tsp.demands = make(map[int]int)
-
-	for scanner.Scan() {
-		line = strings.Trim(scanner.Text(), " ")
-		if line[0] != '#' {
-			elems := utils.Map(strings.Fields(line), func(str string) int {
-				num, err := strconv.Atoi(str)
-				if err != nil {
-					log.Fatal(err)
-				}
-				return num
-			})
-			if i == 0 {
-				// number of nodes
-				tsp.numNodes = elems[0]
-				// capacity of vehicle
-				tsp.capacity = elems[1]
-				// start node
-				tsp.startNode = elems[2]
-
-				// init traveled and carrying if instance contains
-				if len(elems) > 3 {
-					tsp.traveled = elems[3]
-					tsp.carrying = elems[4]
-				}
-				tsp.dueDate = make([]int, tsp.numNodes)
-				tsp.readyTime = make([]int, tsp.numNodes)
-			} else if i <= tsp.numNodes {
-				tsp.matrix = append(tsp.matrix, elems)
-			} else {
-				if len(elems) == 7 {
-					tsp.precedence[elems[1]] = elems[0]
-
-					// TODO: Remove unused pred
-					tsp.pred[elems[1]] = elems[0]
-					tsp.pred[elems[0]] = -elems[1]
-
-					tsp.demands[elems[0]] = elems[2]
-					tsp.demands[elems[1]] = -elems[2]
-					tsp.readyTime[elems[0]] = elems[3]
-					tsp.dueDate[elems[0]] = elems[4]
-					tsp.readyTime[elems[1]] = elems[5]
-					tsp.dueDate[elems[1]] = elems[6]
-				} else if len(elems) == 4 {
-					tsp.precedence[elems[1]] = -1
-					tsp.demands[elems[0]] = elems[1]
-					tsp.readyTime[elems[0]] = elems[2]
-					tsp.dueDate[elems[0]] = elems[3]
-				} else {
-					log.Fatal("Wrong task format")
-				}
-			}
-			i++
+	tsp, err := ParseNamedInstance(name, file)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return tsp
+}
+
+// SetEndNode fixes node at the end of every route, -1 leaves the route open.
+// The end node must differ from the start node and must not precede any node.
+func (tsp *PDPTW) SetEndNode(node int) error {
+	if node != -1 && (node < 0 || node >= tsp.numNodes) {
+		return fmt.Errorf("%w: %d", ErrNodeRange, node)
+	}
+	if node == tsp.startNode {
+		return fmt.Errorf("%w: %d is the start node", ErrEndNode, node)
+	}
+
+	endNode := tsp.endNode
+	tsp.endNode = node
+
+	if err := tsp.checkPrecedence(); err != nil {
+		tsp.endNode = endNode
+		return err
+	}
+	return nil
+}
+
+// EndNode returns the last node of every route, -1 if the route is open
+func (tsp *PDPTW) EndNode() int {
+	return tsp.endNode
+}
+
+// Vehicle of a heterogeneous fleet. The vehicle leaves Start not before Ready
+// with Carrying on board and reaches the last node of its route, End unless
+// the route is open (-1), by Due (0 means no limit). Cost is the cost per time
+// unit of the route duration. Capacity and Carrying have the dimensions of
+// the instance, nil Carrying is empty.
+type Vehicle struct {
+	Capacity Load
+	Start    int
+	End      int
+	Ready    int
+	Due      int
+	Carrying Load
+	Cost     int
+}
+
+// equal returns whether both vehicles have the same attributes
+func (v Vehicle) equal(w Vehicle) bool {
+	return v.Start == w.Start && v.End == w.End && v.Ready == w.Ready && v.Due == w.Due &&
+		v.Cost == w.Cost && v.Capacity.equal(w.Capacity) && v.Carrying.equal(w.Carrying)
+}
+
+// SetVehicles sets the number of vehicles, every vehicle starts at the start
+// node, ends at the end node and has the same capacity. A fleet set before is
+// dropped.
+func (tsp *PDPTW) SetVehicles(vehicles int) error {
+	if vehicles < 1 {
+		return fmt.Errorf("%w: %d vehicles", ErrFleet, vehicles)
+	}
+	tsp.vehicles = vehicles
+	tsp.fleet = nil
+	return nil
+}
+
+// Vehicles returns the number of vehicles
+func (tsp *PDPTW) Vehicles() int {
+	if tsp.fleet != nil {
+		return len(tsp.fleet)
+	}
+	return tsp.vehicles
+}
+
+// SetFleet sets vehicles with their own capacities, depots and shifts, nil
+// returns to the vehicles given by the scalars of the instance
+func (tsp *PDPTW) SetFleet(fleet []Vehicle) error {
+	for k, v := range fleet {
+		if v.Carrying == nil {
+			v.Carrying = make(Load, len(v.Capacity))
+			fleet[k].Carrying = v.Carrying
 		}
 
+		switch {
+		case len(v.Capacity) != tsp.Dimensions() || len(v.Carrying) != tsp.Dimensions():
+			return fmt.Errorf("%w: vehicle %d has capacity %v and load %v of %d dimensions",
+				ErrDimension, k, v.Capacity, v.Carrying, tsp.Dimensions())
+		case v.Start < 0 || v.Start >= tsp.numNodes:
+			return fmt.Errorf("%w: vehicle %d starts at %d", ErrNodeRange, k, v.Start)
+		case v.End < -1 || v.End >= tsp.numNodes:
+			return fmt.Errorf("%w: vehicle %d ends at %d", ErrNodeRange, k, v.End)
+		case v.End == v.Start:
+			return fmt.Errorf("%w: vehicle %d ends at its start node %d", ErrEndNode, k, v.End)
+		case v.Capacity.negative() || v.Carrying.negative() || v.Carrying.exceeds(v.Capacity):
+			return fmt.Errorf("%w: vehicle %d carries %v of capacity %v", ErrFleet, k,
+				v.Carrying, v.Capacity)
+		case v.Due != 0 && v.Ready > v.Due:
+			return fmt.Errorf("%w: vehicle %d has shift [%d, %d]", ErrFleet, k, v.Ready, v.Due)
+		}
 	}
 
-	if err := scanner.Err(); err != nil {
-		log.Fatal(err)
+	previous := tsp.fleet
+	tsp.fleet = fleet
+	if len(fleet) == 0 {
+		tsp.fleet = nil
+	}
+
+	if err := tsp.checkPrecedence(); err != nil {
+		tsp.fleet = previous
+		return err
 	}
-	return &tsp
+	return nil
 }
 
-func (tsp *PDPTW) NumberOfTasks() int {
-	return tsp.numNodes / 2
+// Fleet returns the vehicles, nil if they are identical
+func (tsp *PDPTW) Fleet() []Vehicle {
+	return tsp.fleet
+}
+
+// vehicleAt returns k-th vehicle of the fleet
+func (tsp *PDPTW) vehicleAt(k int) Vehicle {
+	if tsp.fleet != nil {
+		return tsp.fleet[k]
+	}
+	return Vehicle{
+		Capacity: tsp.capacity,
+		Start:    tsp.startNode,
+		End:      tsp.endNode,
+		Ready:    tsp.traveled,
+		Carrying: tsp.carrying,
+		Cost:     1,
+	}
 }
 
-func (tsp *PDPTW) preprocess() {
-	tsp.arcs = make(map[int]map[int]bool)
-	for i, _ := range tsp.matrix {
-		tsp.arcs[i] = make(map[int]bool)
-		for j, _ := range tsp.matrix[i] {
-			if i != j {
-				if tsp.readyTime[i]+tsp.matrix[i][j] > tsp.dueDate[j] {
-					tsp.arcs[i][j] = false
-				} else {
-					tsp.arcs[i][j] = true
-				}
+// isDepot returns whether some vehicle starts or ends at the node
+func (tsp *PDPTW) isDepot(node int) bool {
+	return tsp.isStart(node) || tsp.isEnd(node)
+}
+
+// isStart returns whether some vehicle starts at the node
+func (tsp *PDPTW) isStart(node int) bool {
+	if tsp.fleet == nil {
+		return node == tsp.startNode
+	}
+	for _, v := range tsp.fleet {
+		if v.Start == node {
+			return true
+		}
+	}
+	return false
+}
+
+// isEnd returns whether some vehicle ends at the node
+func (tsp *PDPTW) isEnd(node int) bool {
+	if tsp.fleet == nil {
+		return node == tsp.endNode
+	}
+	for _, v := range tsp.fleet {
+		if v.End == node {
+			return true
+		}
+	}
+	return false
+}
+
+// maxCapacity returns the largest capacity of the fleet in every dimension
+func (tsp *PDPTW) maxCapacity() Load {
+	capacity := make(Load, tsp.Dimensions())
+	for k := 0; k < tsp.Vehicles(); k++ {
+		for i, amount := range tsp.vehicleAt(k).Capacity {
+			if amount > capacity[i] {
+				capacity[i] = amount
 			}
 		}
 	}
+	return capacity
+}
+
+// Dimensions returns the number of dimensions of the capacity
+func (tsp *PDPTW) Dimensions() int {
+	return len(tsp.capacity)
+}
+
+// SetCapacity sets capacity of the vehicles given by the scalars, every
+// dimension of the load has an amount. The initial load is emptied if the
+// number of dimensions changes.
+func (tsp *PDPTW) SetCapacity(capacity Load) error {
+	if len(capacity) == 0 || capacity.negative() {
+		return fmt.Errorf("%w: capacity %v", ErrDimension, capacity)
+	}
+	if len(tsp.carrying) != len(capacity) {
+		tsp.carrying = make(Load, len(capacity))
+	}
+	tsp.capacity = capacity
+	return nil
+}
+
+// demand returns demand of the node, zero in every dimension if it has none
+func (tsp *PDPTW) demand(node int) Load {
+	if demand, ok := tsp.demands[node]; ok {
+		return demand
+	}
+	return make(Load, tsp.Dimensions())
+}
+
+// setPair defines pickup and delivery task
+func (tsp *PDPTW) setPair(pickup, delivery int, demand Load, pickupReady, pickupDue, deliveryReady, deliveryDue int) {
+	tsp.precedence[delivery] = pickup
+	tsp.addPrecedence(pickup, delivery)
+
+	tsp.demands[pickup] = demand
+	tsp.demands[delivery] = demand.negated()
+	tsp.readyTime[pickup] = pickupReady
+	tsp.dueDate[pickup] = pickupDue
+	tsp.readyTime[delivery] = deliveryReady
+	tsp.dueDate[delivery] = deliveryDue
+}
+
+// setSingle defines task without partner
+func (tsp *PDPTW) setSingle(node int, demand Load, readyTime, dueDate int) {
+	tsp.precedence[node] = -1
+	tsp.demands[node] = demand
+	tsp.readyTime[node] = readyTime
+	tsp.dueDate[node] = dueDate
+}
+
+// SetCoordinates sets coordinates of nodes and rebuilds the matrix with the
+// given metric, returns error if the coordinates do not match the nodes or a
+// distance does not fit into int32
+func (tsp *PDPTW) SetCoordinates(coords []Point, metric Metric) error {
+	if len(coords) != tsp.numNodes {
+		return fmt.Errorf("%w: %d coordinates of %d nodes", ErrDimension, len(coords),
+			tsp.numNodes)
+	}
+	matrix, err := buildMatrix(coords, metric)
+	if err != nil {
+		return err
+	}
+	tsp.coords = coords
+	tsp.metric = metric
+	tsp.matrix = matrix
+	return nil
+}
+
+// pairs returns pickup and delivery pairs ordered by pickup
+func (tsp *PDPTW) pairs() (pairs [][2]int) {
+	for node := 0; node < tsp.numNodes; node++ {
+		if pickup, ok := tsp.precedence[node]; ok && pickup >= 0 {
+			pairs = append(pairs, [2]int{pickup, node})
+		}
+	}
+
+	sort.Slice(pairs, func(i, j int) bool {
+		return pairs[i][0] < pairs[j][0]
+	})
+	return
+}
+
+// SetServiceTimes sets duration of the service at every node, returns error
+// if the durations do not match the nodes or some is negative
+func (tsp *PDPTW) SetServiceTimes(serviceTime []int) error {
+	if len(serviceTime) != tsp.numNodes {
+		return fmt.Errorf("%w: %d service times of %d nodes", ErrDimension, len(serviceTime),
+			tsp.numNodes)
+	}
+	for node, service := range serviceTime {
+		if service < 0 {
+			return fmt.Errorf("%w: node %d has service time %d", ErrTaskFormat, node, service)
+		}
+	}
+	tsp.serviceTime = append([]int(nil), serviceTime...)
+	return nil
+}
+
+// depart returns time of departure from node reached at arrival, the vehicle
+// waits to the ready time or the next window and serves the node
+func (tsp *PDPTW) depart(node, arrival int) int {
+	return tsp.start(node, arrival) + tsp.serviceTime[node]
+}
+
+// Name returns name of the instance
+func (tsp *PDPTW) Name() string {
+	return tsp.name
+}
+
+// Coordinates returns coordinates of nodes, nil if the instance has only matrix
+func (tsp *PDPTW) Coordinates() []Point {
+	return tsp.coords
+}
+
+func (tsp *PDPTW) NumberOfTasks() int {
+	return tsp.numNodes / 2
 }
 
 // Print the instance in human readable form
@@ -169,10 +567,14 @@ Instance name:      %s
 Number of vertices: %v
 readyTime:          %3v
 dueDate:            %3v
+serviceTime:        %3v
 Demands:          	%v
 Precendeces:		%v
-`, tsp.name, tsp.numNodes, tsp.readyTime, tsp.dueDate, tsp.demands, tsp.precedence)
-	for _, line := range tsp.matrix {
+`, tsp.name, tsp.numNodes, tsp.readyTime, tsp.dueDate, tsp.serviceTime, tsp.demands, tsp.precedence)
+	if tsp.coords != nil {
+		fmt.Printf("Coordinates:		%v\n", tsp.coords)
+	}
+	for _, line := range tsp.matrix.rows() {
 		fmt.Printf("%2v\n", line)
 	}
 }
@@ -0,0 +1,570 @@
+package core
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+)
+
+// Plan is a solution of an instance with several vehicles. Every route starts
+// at the start node, ends at the end node if there is one and serves whole
+// requests, routes without any request are dropped.
+type Plan struct {
+	routes []*Solution
+	tsp    *PDPTW
+	// requests are groups of nodes served by one vehicle, request maps node
+	// to its group, -1 for the start and end node
+	requests [][]int
+	request  []int
+}
+
+// NewPlan returns a plan of the given routes, the k-th route is served by the
+// k-th vehicle
+func NewPlan(tsp *PDPTW, routes [][]int) *Plan {
+	p := newPlan(tsp)
+	for k, route := range routes {
+		p.routes = append(p.routes, &Solution{route: route, tsp: tsp, vehicle: k})
+	}
+	return p
+}
+
+func newPlan(tsp *PDPTW) *Plan {
+	p := &Plan{tsp: tsp, requests: tsp.requests(), request: make([]int, tsp.numNodes)}
+	for node := range p.request {
+		p.request[node] = -1
+	}
+	for i, request := range p.requests {
+		for _, node := range request {
+			p.request[node] = i
+		}
+	}
+	return p
+}
+
+// requests returns groups of nodes bound by precedence which must be served
+// by the same vehicle, e.g. pickup and delivery pairs. Nodes of a group are
+// in topological order, groups are ordered by their first node. The depots
+// of the vehicles belong to no group.
+func (tsp *PDPTW) requests() (requests [][]int) {
+	inner := func(node int) bool {
+		return !tsp.isDepot(node)
+	}
+
+	// union find over the precedence rules
+	parent := make([]int, tsp.numNodes)
+	for node := range parent {
+		parent[node] = node
+	}
+	var find func(int) int
+	find = func(node int) int {
+		if parent[node] != node {
+			parent[node] = find(parent[node])
+		}
+		return parent[node]
+	}
+	for first, followers := range tsp.after {
+		for _, second := range followers {
+			if inner(first) && inner(second) {
+				parent[find(second)] = find(first)
+			}
+		}
+	}
+
+	// Kahn's algorithm visiting the nodes in topological order
+	indegree := make([]int, tsp.numNodes)
+	for node := range tsp.before {
+		indegree[node] = len(tsp.before[node])
+	}
+	queue := []int{}
+	for node, degree := range indegree {
+		if degree == 0 {
+			queue = append(queue, node)
+		}
+	}
+
+	group := make(map[int]int)
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+
+		for _, next := range tsp.after[node] {
+			indegree[next]--
+			if indegree[next] == 0 {
+				queue = append(queue, next)
+			}
+		}
+
+		if !inner(node) {
+			continue
+		}
+		root := find(node)
+		if _, ok := group[root]; !ok {
+			group[root] = len(requests)
+			requests = append(requests, nil)
+		}
+		requests[group[root]] = append(requests[group[root]], node)
+	}
+	return
+}
+
+// Routes returns routes of the used vehicles
+func (p *Plan) Routes() []*Solution {
+	return p.routes
+}
+
+// Vehicles returns the number of used vehicles
+func (p *Plan) Vehicles() int {
+	return len(p.routes)
+}
+
+// MakeSpan returns the latest arrival of all vehicles
+func (p *Plan) MakeSpan() (makeSpan int) {
+	for _, route := range p.routes {
+		if span := route.MakeSpan(); span > makeSpan {
+			makeSpan = span
+		}
+	}
+	return
+}
+
+// TotalDistance returns the sum of distances of all routes
+func (p *Plan) TotalDistance() (total int) {
+	for _, route := range p.routes {
+		total += route.TotalDistance()
+	}
+	return
+}
+
+// windowCost returns the cost of the service outside of the soft windows
+func (p *Plan) windowCost() (cost int) {
+	for _, route := range p.routes {
+		cost += route.windowCost()
+	}
+	return
+}
+
+// Copy makes a deep copy of the plan
+func (p *Plan) Copy() *Plan {
+	x := *p
+	x.routes = make([]*Solution, len(p.routes))
+	for i, route := range p.routes {
+		x.routes[i] = route.Copy()
+	}
+	return &x
+}
+
+// IsFeasible checks that every vehicle serves at most one route, every node
+// is served exactly once, optional ones at most once, and all routes are
+// feasible
+func (p *Plan) IsFeasible() bool {
+	return p.violation() == ""
+}
+
+// violation describes the first broken constraint, empty if there is none
+func (p *Plan) violation() string {
+	used := make([]bool, p.tsp.Vehicles())
+	visits := make([]int, p.tsp.numNodes)
+	for _, route := range p.routes {
+		if route.vehicle < 0 || route.vehicle >= len(used) || used[route.vehicle] {
+			return fmt.Sprintf("route %v has vehicle %d used or out of the fleet",
+				route.route, route.vehicle)
+		}
+		used[route.vehicle] = true
+
+		if len(route.route) == 0 || route.route[0] != p.tsp.vehicleAt(route.vehicle).Start {
+			return fmt.Sprintf("route %v does not start at the start node of vehicle %d",
+				route.route, route.vehicle)
+		}
+		for _, node := range route.route {
+			if p.request[node] >= 0 {
+				visits[node]++
+			}
+		}
+		if excess := route.rideExcess(); excess > 0 {
+			return fmt.Sprintf("route %v exceeds maximum ride times by %d", route.route, excess)
+		}
+		if violations := route.loadingViolations(); violations > 0 {
+			return fmt.Sprintf("route %v violates %s loading %d times", route.route,
+				p.tsp.loading, violations)
+		}
+		if excess := route.shiftExcess(); excess > 0 {
+			return fmt.Sprintf("route %v exceeds maximum duration or break by %d", route.route,
+				excess)
+		}
+		if !route.IsFeasible() {
+			return fmt.Sprintf("route %v is not feasible", route.route)
+		}
+	}
+
+	for node, count := range visits {
+		if p.request[node] >= 0 && count != 1 && (count != 0 || !p.tsp.isOptional(node)) {
+			return fmt.Sprintf("node %d is visited %d times", node, count)
+		}
+	}
+	return ""
+}
+
+// Check logs the broken constraint and returns whether the plan is feasible
+func (p *Plan) Check() bool {
+	if violation := p.violation(); violation != "" {
+		log.Errorf("Plan is not FEASIBLE, %s", violation)
+		return false
+	}
+	return true
+}
+
+// Print the plan, route per line prefixed by the vehicle
+func (p *Plan) Print() {
+	for _, route := range p.routes {
+		fmt.Printf("%d: %s\n", route.vehicle, strings.Join(route.strings(), " -> "))
+	}
+}
+
+// emptyRoute returns route of the k-th vehicle serving no request
+func (p *Plan) emptyRoute(k int) *Solution {
+	v := p.tsp.vehicleAt(k)
+	route := []int{v.Start}
+	if v.End >= 0 {
+		route = append(route, v.End)
+	}
+	return &Solution{route: route, tsp: p.tsp, vehicle: k}
+}
+
+// unused returns empty routes of the unused vehicles, one per distinct vehicle
+func (p *Plan) unused() (routes []*Solution) {
+	used := make([]bool, p.tsp.Vehicles())
+	for _, route := range p.routes {
+		used[route.vehicle] = true
+	}
+
+	var seen []Vehicle
+outer:
+	for k, ok := range used {
+		if ok {
+			continue
+		}
+		v := p.tsp.vehicleAt(k)
+		for _, w := range seen {
+			if v.equal(w) {
+				continue outer
+			}
+		}
+		seen = append(seen, v)
+		routes = append(routes, p.emptyRoute(k))
+	}
+	return
+}
+
+// isEmpty returns whether the route serves no request
+func (p *Plan) isEmpty(s *Solution) bool {
+	for _, node := range s.route {
+		if p.request[node] >= 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// requestsOf returns indices of requests served by the route
+func (p *Plan) requestsOf(s *Solution) (requests []int) {
+	seen := make(map[int]bool)
+	for _, node := range s.route {
+		if request := p.request[node]; request >= 0 && !seen[request] {
+			seen[request] = true
+			requests = append(requests, request)
+		}
+	}
+	return
+}
+
+// remove returns copy of the route without nodes of the request
+func (p *Plan) remove(s *Solution, request int) *Solution {
+	route := make([]int, 0, len(s.route))
+	for _, node := range s.route {
+		if p.request[node] != request {
+			route = append(route, node)
+		}
+	}
+	return &Solution{route: route, tsp: s.tsp, vehicle: s.vehicle}
+}
+
+// append returns copy of the route with nodes of the request added before
+// the end node regardless of feasibility
+func (p *Plan) append(s *Solution, request int) *Solution {
+	x := &Solution{route: append([]int{}, s.route[:s.movable()]...), tsp: s.tsp,
+		vehicle: s.vehicle}
+	x.route = append(x.route, p.requests[request]...)
+	x.route = append(x.route, s.route[s.movable():]...)
+	return x
+}
+
+// replace returns plan with routes i and j replaced, index len(routes)
+// stands for an unused vehicle and routes left empty are dropped. The other
+// routes are shared with p.
+func (p *Plan) replace(i int, si *Solution, j int, sj *Solution) *Plan {
+	x := *p
+	x.routes = make([]*Solution, 0, len(p.routes)+1)
+
+	for k := 0; k <= len(p.routes); k++ {
+		var route *Solution
+		switch {
+		case k == i:
+			route = si
+		case k == j:
+			route = sj
+		case k < len(p.routes):
+			route = p.routes[k]
+		}
+		if route != nil && !p.isEmpty(route) {
+			x.routes = append(x.routes, route)
+		}
+	}
+	return &x
+}
+
+// insert returns copy of the route with the request inserted at the feasible
+// positions with the least cost, nil if there are none. Pickup and delivery
+// pairs try all positions, larger requests are inserted node by node.
+func (p *Plan) insert(s *Solution, request int, o planObjective) *Solution {
+	nodes := p.requests[request]
+	if len(nodes) == 2 && p.tsp.demands[nodes[0]].cancels(p.tsp.demands[nodes[1]]) {
+		if x, ok := s.insertPair(nodes[0], nodes[1], 1, o); ok {
+			return x
+		}
+	}
+	return s.insertNodes(nodes, 1, o)
+}
+
+// insertNodes returns copy of the route with the nodes inserted one by one
+// at the positions from the given one with the least cost, nil if one of
+// them fits nowhere. The route is feasible after the last node.
+func (s *Solution) insertNodes(nodes []int, from int, o planObjective) *Solution {
+	x := s
+	for k, node := range nodes {
+		last := k == len(nodes)-1
+
+		// after the predecessors already in the route
+		first := from
+		for i, prev := range x.route {
+			for _, pred := range s.tsp.before[node] {
+				if prev == pred && i+1 > first {
+					first = i + 1
+				}
+			}
+		}
+
+		var best *Solution
+		bestCost := 0
+		for i := first; i <= x.movable(); i++ {
+			candidate := &Solution{route: insertAt(x.route, node, i), tsp: s.tsp,
+				vehicle: s.vehicle}
+			if last && !candidate.IsFeasible() || !last && !candidate.meetsWindows() {
+				continue
+			}
+			if c := o.route(candidate); best == nil || c < bestCost {
+				best, bestCost = candidate, c
+			}
+		}
+		if best == nil {
+			return nil
+		}
+		x = best
+	}
+	return x
+}
+
+// insertPair inserts pickup and delivery with cancelling demands into the
+// feasible route in O(n^2) using the latest start times of the route and
+// pushing the times forward, the pickup goes to position from or later.
+// Returns false if the fast evaluation cannot be used, the result is then
+// computed node by node.
+func (s *Solution) insertPair(pickup, delivery, from int, o planObjective) (*Solution, bool) {
+	tsp := s.tsp
+	route := s.route
+	n := len(route)
+
+	if !s.IsFeasible() {
+		return nil, false
+	}
+
+	// start of service, load after service, latest start of service
+	// keeping the rest of the route feasible and cost of soft windows
+	start := make([]int, n)
+	load := make([]Load, n)
+	latest := make([]int, n)
+	cost := make([]int, n)
+
+	v := tsp.vehicleAt(s.vehicle)
+	arrival, carrying := v.Ready, v.Carrying.copy()
+	for k, node := range route {
+		if k > 0 {
+			arrival = start[k-1] + tsp.serviceTime[route[k-1]] + tsp.travel(route[k-1], node)
+		}
+		start[k] = tsp.start(node, arrival)
+		carrying.add(tsp.demands[node])
+		load[k] = carrying.copy()
+		cost[k] = tsp.windowCost(node, start[k])
+	}
+	makeSpan, distance := s.MakeSpan(), s.TotalDistance()
+
+	latest[n-1] = tsp.due(route[n-1])
+	for k := n - 2; k >= 0; k-- {
+		latest[k] = latest[k+1] - tsp.travel(route[k], route[k+1]) - tsp.serviceTime[route[k]]
+		if due := tsp.due(route[k]); due < latest[k] {
+			latest[k] = due
+		}
+		// inside a window, earlier arrivals wait at most to it
+		latest[k] = tsp.latestStart(route[k], latest[k])
+	}
+
+	// pushForward returns makespan after arriving at k at time a and the
+	// change of the cost of soft windows of the nodes pushed
+	pushForward := func(k, a int) (int, int) {
+		diff := 0
+		for ; k < n-1; k++ {
+			t := tsp.start(route[k], a)
+			if t == start[k] {
+				return makeSpan, diff
+			}
+			diff += tsp.windowCost(route[k], t) - cost[k]
+			a = t + tsp.serviceTime[route[k]] + tsp.travel(route[k], route[k+1])
+		}
+		return a, diff + tsp.windowCost(route[k], tsp.start(route[k], a)) - cost[k]
+	}
+
+	demand := tsp.demands[pickup]
+	rideLimit := tsp.MaxRideTime(delivery)
+	bestI, bestQ, bestCost := -1, -1, 0
+	// candidates are the positions and costs of all insertions with route rules
+	var candidates [][3]int
+
+	// pickup is inserted before route[i], delivery before route[q]
+	for i := from; i <= s.movable(); i++ {
+		prev := route[i-1]
+		tp := tsp.start(pickup, start[i-1]+tsp.serviceTime[prev]+tsp.travel(prev, pickup))
+		if tp > tsp.due(pickup) || load[i-1].exceedsWith(demand, v.Capacity) {
+			continue
+		}
+
+		pickupDistance := tsp.travel(prev, pickup)
+		if i < n {
+			pickupDistance -= tsp.travel(prev, route[i])
+		}
+
+		// soft windows of the pickup and the nodes passed with it on board
+		last, t, soft := pickup, tp, tsp.windowCost(pickup, tp)
+		// open are the deliveries of the items loaded after the pickup
+		var open []int
+		for q := i; q <= s.movable(); q++ {
+			if q > i {
+				// route[q-1] is passed with the pickup on board
+				a := t + tsp.serviceTime[last] + tsp.travel(last, route[q-1])
+				if a > latest[q-1] || load[q-1].exceedsWith(demand, v.Capacity) {
+					break
+				}
+				last, t = route[q-1], tsp.start(route[q-1], a)
+				soft += tsp.windowCost(last, t) - cost[q-1]
+
+				var ok bool
+				if open, ok = tsp.passLoading(open, last); !ok {
+					break
+				}
+			}
+			if tsp.loading == LIFO && len(open) > 0 {
+				continue
+			}
+
+			ad := t + tsp.serviceTime[last] + tsp.travel(last, delivery)
+			td := tsp.start(delivery, ad)
+			if td > tsp.due(delivery) || rideLimit != 0 && td-tp-tsp.serviceTime[pickup] > rideLimit {
+				continue
+			}
+
+			dist := distance + pickupDistance + tsp.travel(last, delivery)
+			if q > i {
+				dist += tsp.travel(pickup, route[i])
+			}
+
+			span, pushed := ad, 0
+			if q < n {
+				a := td + tsp.serviceTime[delivery] + tsp.travel(delivery, route[q])
+				if a > latest[q] {
+					continue
+				}
+				dist += tsp.travel(delivery, route[q])
+				if q > i {
+					dist -= tsp.travel(last, route[q])
+				}
+				span, pushed = pushForward(q, a)
+			}
+
+			if v.Due != 0 && span > v.Due {
+				// end of the shift
+				continue
+			}
+
+			c := o.routeCost(v, dist, span) + soft + tsp.windowCost(delivery, td) + pushed
+			if bestI < 0 || c < bestCost {
+				bestI, bestQ, bestCost = i, q, c
+			}
+			if tsp.hasRouteRules() {
+				candidates = append(candidates, [3]int{i, q, c})
+			}
+		}
+	}
+
+	if bestI < 0 {
+		return nil, true
+	}
+
+	x := &Solution{route: insertAt(insertAt(route, pickup, bestI), delivery, bestQ+1), tsp: tsp,
+		vehicle: s.vehicle}
+	if len(candidates) == 0 || x.IsFeasible() {
+		return x, x.IsFeasible()
+	}
+
+	// delays may break ride times of the pairs pushed and FIFO loading
+	// depends on the items on board, the candidates are checked by their cost
+	sort.SliceStable(candidates, func(a, b int) bool {
+		return candidates[a][2] < candidates[b][2]
+	})
+	for _, c := range candidates {
+		x := &Solution{route: insertAt(insertAt(route, pickup, c[0]), delivery, c[1]+1),
+			tsp: tsp, vehicle: s.vehicle}
+		if x.IsFeasible() {
+			return x, true
+		}
+	}
+	return nil, true
+}
+
+// insertAt returns copy of route with node at position pos
+func insertAt(route []int, node, pos int) []int {
+	x := make([]int, 0, len(route)+1)
+	x = append(x, route[:pos]...)
+	x = append(x, node)
+	return append(x, route[pos:]...)
+}
+
+// shuffle returns copy of the route with the movable nodes in random order
+func (s *Solution) shuffle() *Solution {
+	x := s.Copy()
+	rand.Shuffle(x.movable()-1, func(i, j int) {
+		x.route[i+1], x.route[j+1] = x.route[j+1], x.route[i+1]
+	})
+	return x
+}
+
+// meetsWindows returns whether all nodes of the route are reached by their
+// due dates, capacity and precedence are not checked
+func (s *Solution) meetsWindows() bool {
+	traveled := s.tsp.vehicleAt(s.vehicle).Ready
+	for i := 1; i < len(s.route); i++ {
+		traveled = s.tsp.depart(s.route[i-1], traveled) + s.tsp.travel(s.route[i-1], s.route[i])
+		if s.tsp.due(s.route[i]) < traveled {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,240 @@
+package core
+
+import (
+	"math/rand"
+
+	"github.com/mitas1/psa-core/config"
+)
+
+// planVND improves every route by the local search and moves requests
+// between the routes until no move improves the plan
+type planVND struct {
+	search    localSearch
+	objective planObjective
+}
+
+func (v planVND) process(p *Plan) {
+	for {
+		improved := false
+		for i, route := range p.routes {
+			if route.movable() <= 2 {
+				continue
+			}
+			s := route.Copy()
+			v.search.process(s)
+			if x := p.replace(i, s, -1, nil); v.objective.better(x, p) {
+				*p = *x
+				improved = true
+			}
+		}
+
+		if !v.relocate(p) && !v.exchange(p) && !v.serve(p) && !improved {
+			return
+		}
+	}
+}
+
+// relocate moves a request to the cheapest feasible positions of another
+// route or of an unused vehicle, returns whether the plan improved
+func (v planVND) relocate(p *Plan) bool {
+	targets := append(p.routes[:len(p.routes):len(p.routes)], p.unused()...)
+
+	for a, route := range p.routes {
+		for _, request := range p.requestsOf(route) {
+			removed := p.remove(route, request)
+			if !removed.IsFeasible() {
+				continue
+			}
+
+			for b, target := range targets {
+				if b == a {
+					continue
+				}
+
+				inserted := p.insert(target, request, v.objective)
+				if inserted == nil {
+					continue
+				}
+
+				if b > len(p.routes) {
+					b = len(p.routes)
+				}
+				if x := p.replace(a, removed, b, inserted); v.objective.better(x, p) {
+					*p = *x
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// serve rejects a served optional request or inserts an unserved one at the
+// cheapest feasible positions of a route or of an unused vehicle, returns
+// whether the plan improved
+func (v planVND) serve(p *Plan) bool {
+	for a, route := range p.routes {
+		for _, request := range p.requestsOf(route) {
+			if !p.isOptional(request) {
+				continue
+			}
+			removed := p.remove(route, request)
+			if !removed.IsFeasible() {
+				continue
+			}
+			if x := p.replace(a, removed, -1, nil); v.objective.better(x, p) {
+				*p = *x
+				return true
+			}
+		}
+	}
+
+	for _, request := range p.unserved() {
+		for b, target := range append(p.routes[:len(p.routes):len(p.routes)], p.unused()...) {
+			inserted := p.insert(target, request, v.objective)
+			if inserted == nil {
+				continue
+			}
+
+			if b > len(p.routes) {
+				b = len(p.routes)
+			}
+			if x := p.replace(b, inserted, -1, nil); v.objective.better(x, p) {
+				*p = *x
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// exchange swaps two requests of different routes, each is inserted at the
+// cheapest feasible positions of the other route, returns whether the plan
+// improved
+func (v planVND) exchange(p *Plan) bool {
+	for a := 0; a < len(p.routes); a++ {
+		for b := a + 1; b < len(p.routes); b++ {
+			for _, ra := range p.requestsOf(p.routes[a]) {
+				removedA := p.remove(p.routes[a], ra)
+
+				for _, rb := range p.requestsOf(p.routes[b]) {
+					removedB := p.remove(p.routes[b], rb)
+
+					insertedA := p.insert(removedA, rb, v.objective)
+					if insertedA == nil {
+						continue
+					}
+					insertedB := p.insert(removedB, ra, v.objective)
+					if insertedB == nil {
+						continue
+					}
+
+					if x := p.replace(a, insertedA, b, insertedB); v.objective.better(x, p) {
+						*p = *x
+						return true
+					}
+				}
+			}
+		}
+	}
+	return false
+}
+
+// planVNS perturbs the best plan by relocating random requests and by
+// disturbing its routes, the result is improved by planVND
+type planVNS struct {
+	vnd      planVND
+	levelMax int
+	iterMax  int
+}
+
+// default perturbation of plans optimized by the simulated annealing only,
+// the values of the bundled configuration
+const (
+	planLevelMax = 30
+	planIterMax  = 2
+)
+
+func newPlanVNS(opts config.Optimization, objective planObjective) planVNS {
+	search := opts.VNS.LocalSearch
+	levelMax, iterMax := opts.VNS.LevelMax, opts.VNS.IterMax
+	if opts.VNS == (config.VNS{}) {
+		search = opts.SA.LocalSearch
+		levelMax, iterMax = planLevelMax, planIterMax
+	}
+	// routes are improved by the makespan unless only the distance counts
+	if objective.makeSpan == 0 && objective.distance > 0 {
+		opts.Objective = "time"
+	}
+	return planVNS{
+		vnd:      planVND{search: getLocalSearch(search, NewObjective(opts)), objective: objective},
+		levelMax: levelMax,
+		iterMax:  iterMax,
+	}
+}
+
+func (v planVNS) process(p *Plan) *Plan {
+	level := 1
+	iterLevel := 0
+
+	best := p.Copy()
+
+	v.vnd.process(best)
+
+	for level < v.levelMax {
+		x := v.disturb(best, level)
+
+		v.vnd.process(x)
+
+		if v.vnd.objective.better(x, best) {
+			iterLevel = 0
+			level = 1
+			best = x
+		} else {
+			if iterLevel > v.iterMax {
+				level++
+				iterLevel = 0
+			}
+		}
+
+		iterLevel++
+	}
+
+	return best
+}
+
+// disturb returns copy of p with level random requests relocated to random
+// routes where they fit and with a random route disturbed
+func (v planVNS) disturb(p *Plan, level int) *Plan {
+	x := p.Copy()
+
+	for j := 0; j < level && len(x.routes) > 0; j++ {
+		a := rand.Intn(len(x.routes))
+		requests := x.requestsOf(x.routes[a])
+		request := requests[rand.Intn(len(requests))]
+
+		targets := append(x.routes[:len(x.routes):len(x.routes)], x.unused()...)
+		b := rand.Intn(len(targets))
+		if b == a {
+			continue
+		}
+		target := targets[b]
+		if b > len(x.routes) {
+			b = len(x.routes)
+		}
+
+		removed := x.remove(x.routes[a], request)
+		inserted := x.insert(target, request, v.vnd.objective)
+		if inserted != nil && removed.IsFeasible() {
+			x = x.replace(a, removed, b, inserted)
+		}
+	}
+
+	if len(x.routes) > 0 {
+		a := rand.Intn(len(x.routes))
+		if x.routes[a].movable() > 2 {
+			x.routes[a] = x.routes[a].disturb(level)
+		}
+	}
+	return x
+}
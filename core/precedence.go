@@ -0,0 +1,119 @@
+package core
+
+import (
+	"fmt"
+	"sort"
+)
+
+// addPrecedence requires node first to be visited before node second
+func (tsp *PDPTW) addPrecedence(first, second int) {
+	tsp.after[first] = append(tsp.after[first], second)
+	tsp.before[second] = append(tsp.before[second], first)
+}
+
+// AddPrecedence requires node first to be visited before node second. Pickups
+// precede their deliveries without it. Fails if a node is out of range or the
+// rule closes a cycle.
+func (tsp *PDPTW) AddPrecedence(first, second int) error {
+	for _, node := range []int{first, second} {
+		if node < 0 || node >= tsp.numNodes {
+			return fmt.Errorf("%w: %d", ErrNodeRange, node)
+		}
+	}
+
+	tsp.addPrecedence(first, second)
+
+	if err := tsp.checkPrecedence(); err != nil {
+		tsp.after[first] = tsp.after[first][:len(tsp.after[first])-1]
+		tsp.before[second] = tsp.before[second][:len(tsp.before[second])-1]
+		return err
+	}
+	return nil
+}
+
+// checkPrecedence returns error if the precedence graph has a cycle, a start
+// node has to follow some node or an end node has to precede some node
+func (tsp *PDPTW) checkPrecedence() error {
+	for k := 0; k < tsp.Vehicles(); k++ {
+		v := tsp.vehicleAt(k)
+		if len(tsp.before[v.Start]) > 0 {
+			return fmt.Errorf("%w: start node %d follows %v", ErrPrecedence,
+				v.Start, tsp.before[v.Start])
+		}
+		if v.End >= 0 && len(tsp.after[v.End]) > 0 {
+			return fmt.Errorf("%w: end node %d precedes %v", ErrPrecedence,
+				v.End, tsp.after[v.End])
+		}
+	}
+
+	// Kahn's algorithm, nodes left with predecessors lie on or behind a cycle
+	indegree := make([]int, tsp.numNodes)
+	for node := range tsp.before {
+		indegree[node] = len(tsp.before[node])
+	}
+
+	queue := []int{}
+	for node, degree := range indegree {
+		if degree == 0 {
+			queue = append(queue, node)
+		}
+	}
+
+	for len(queue) > 0 {
+		node := queue[len(queue)-1]
+		queue = queue[:len(queue)-1]
+
+		for _, next := range tsp.after[node] {
+			indegree[next]--
+			if indegree[next] == 0 {
+				queue = append(queue, next)
+			}
+		}
+	}
+
+	var cycle []int
+	for node, degree := range indegree {
+		if degree > 0 {
+			cycle = append(cycle, node)
+		}
+	}
+
+	if cycle != nil {
+		return fmt.Errorf("%w: cycle through nodes %v", ErrPrecedence, cycle)
+	}
+	return nil
+}
+
+// precedences returns all rules as (first, second) ordered by first, without
+// the pickup and delivery pairs unless pairs is set
+func (tsp *PDPTW) precedences(pairs bool) (rules [][2]int) {
+	for first, nodes := range tsp.after {
+		for _, second := range nodes {
+			if pickup, ok := tsp.precedence[second]; !pairs && ok && pickup == first {
+				continue
+			}
+			rules = append(rules, [2]int{first, second})
+		}
+	}
+
+	sort.Slice(rules, func(i, j int) bool {
+		if rules[i][0] != rules[j][0] {
+			return rules[i][0] < rules[j][0]
+		}
+		return rules[i][1] < rules[j][1]
+	})
+	return
+}
+
+// positions returns position of every node of the route, nodes served by
+// another vehicle get numNodes as if they were visited after the route
+func (s *Solution) positions() []int {
+	position := make([]int, s.tsp.numNodes)
+	for node := range position {
+		position[node] = s.tsp.numNodes
+	}
+	for i, node := range s.route {
+		position[node] = i
+	}
+	return position
+}
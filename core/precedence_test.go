@@ -0,0 +1,35 @@
+package core
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParsePrecedenceCycle(t *testing.T) {
+	// 1 -> 2 -> 3 -> 4 -> 1 with the pairs
+	text := testPSA + "precedence 2 3\nprecedence 4 1\n"
+	_, err := ParseNamedInstance("test.psa", strings.NewReader(text))
+
+	var parseErr *ParseError
+	if !errors.As(err, &parseErr) || parseErr.Field != "precedence" ||
+		!errors.Is(err, ErrPrecedence) {
+		t.Fatalf("got %v, want precedence error", err)
+	}
+}
+
+func TestAddPrecedenceCycle(t *testing.T) {
+	tsp := parseTestInstance(t, "precedence 2 3")
+	rules := tsp.precedences(true)
+
+	if err := tsp.AddPrecedence(4, 1); !errors.Is(err, ErrPrecedence) {
+		t.Fatalf("got %v, want precedence error", err)
+	}
+	if !reflect.DeepEqual(tsp.precedences(true), rules) {
+		t.Fatalf("rules %v, want %v", tsp.precedences(true), rules)
+	}
+	if err := tsp.checkPrecedence(); err != nil {
+		t.Fatal(err)
+	}
+}
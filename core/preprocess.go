@@ -0,0 +1,270 @@
+package core
+
+import (
+	"math"
+)
+
+// maxPreprocessPasses bounds the number of tightening passes of Preprocess
+const maxPreprocessPasses = 100
+
+// noDue stands for the missing due date of a node
+const noDue = math.MaxInt32
+
+// PreprocessReport summarizes reductions of Preprocess, the window units are
+// the sums of the shifts of ready times and due dates
+type PreprocessReport struct {
+	Passes         int `json:"passes"`
+	Arcs           int `json:"arcs"`
+	ArcsRemoved    int `json:"arcsRemoved"`
+	ReadyTightened int `json:"readyTightened"`
+	DueTightened   int `json:"dueTightened"`
+	// Infeasible nodes have empty time window or no arc left to reach them,
+	// nodes of optional requests are not reported
+	Infeasible []int `json:"infeasible,omitempty"`
+}
+
+// Preprocess tightens time windows and eliminates arcs which cannot be part of
+// any feasible route. Arcs into the start nodes, out of the end nodes, from
+// the start to the end node of a vehicle or to nodes with a predecessor,
+// against the precedence and between nodes whose demands exceed the largest
+// capacity together in some dimension are removed first. Then until nothing changes:
+//
+//   - arcs which miss the due date of their head are removed
+//   - ready time is raised to the earliest arrival over the incoming arcs
+//   - due date is lowered to the latest arrival over the incoming arcs
+//   - due date of a node with followers, e.g. pickup, is lowered so a
+//     successor and the followers can be reached in time
+//   - ready time of a follower, e.g. delivery, is raised so its predecessors
+//     can be served first
+//
+// Due dates 0 mean no due date and are kept. The bounds hold for every
+// feasible route, so the instance keeps all its feasible solutions. The
+// windows are changed in place, Core preprocesses a copy of the instance.
+func (tsp *PDPTW) Preprocess() PreprocessReport {
+	n := tsp.numNodes
+	r := PreprocessReport{Arcs: n * (n - 1)}
+
+	// a node may end the route of one vehicle and start another one
+	var starts []int
+	for node := 0; node < n; node++ {
+		if tsp.isStart(node) {
+			starts = append(starts, node)
+		}
+	}
+
+	tsp.arcs = make([][]bool, n)
+	for i := range tsp.arcs {
+		tsp.arcs[i] = make([]bool, n)
+		out := !tsp.isEnd(i) || tsp.isStart(i)
+		for j := range tsp.arcs[i] {
+			tsp.arcs[i][j] = out && i != j && (!tsp.isStart(j) || tsp.isEnd(j))
+		}
+	}
+
+	for _, rule := range tsp.precedences(true) {
+		first, second := rule[0], rule[1]
+
+		tsp.arcs[second][first] = false
+		for _, start := range starts {
+			if first != start {
+				tsp.arcs[start][second] = false
+			}
+		}
+	}
+
+	for k := 0; k < tsp.Vehicles(); k++ {
+		if v := tsp.vehicleAt(k); v.End >= 0 {
+			tsp.arcs[v.Start][v.End] = n == 2
+		}
+	}
+
+	capacity := tsp.maxCapacity()
+	for i := 0; i < n; i++ {
+		// loads of both nodes are on board after the arc
+		first := tsp.demands[i]
+		if first == nil || first.negative() {
+			continue
+		}
+		for j := 0; j < n; j++ {
+			second := tsp.demands[j]
+			if second != nil && !second.negative() && first.exceedsWith(second, capacity) {
+				tsp.arcs[i][j] = false
+			}
+		}
+	}
+
+	for changed := true; changed && r.Infeasible == nil && r.Passes < maxPreprocessPasses; {
+		r.Passes++
+		changed = tsp.tighten(&r)
+	}
+
+	for i := range tsp.arcs {
+		for j, ok := range tsp.arcs[i] {
+			if i != j && !ok {
+				r.ArcsRemoved++
+			}
+		}
+	}
+	return r
+}
+
+// due returns due date of node, noDue if it has none or it is soft
+func (tsp *PDPTW) due(node int) int {
+	if tsp.dueDate[node] == 0 || tsp.isSoft(node) {
+		return noDue
+	}
+	return tsp.dueDate[node]
+}
+
+// hasArc returns whether node j may follow node i, all arcs are allowed
+// before Preprocess
+func (tsp *PDPTW) hasArc(i, j int) bool {
+	return tsp.arcs == nil || tsp.arcs[i][j]
+}
+
+// tighten makes one pass of arc elimination and window tightening, returns
+// whether anything changed
+func (tsp *PDPTW) tighten(r *PreprocessReport) (changed bool) {
+	n := tsp.numNodes
+
+	// departures of the vehicles from their start nodes
+	early, late := make(map[int]int), make(map[int]int)
+	for k := 0; k < tsp.Vehicles(); k++ {
+		v := tsp.vehicleAt(k)
+		start := tsp.depart(v.Start, v.Ready)
+		if t, ok := early[v.Start]; !ok || start < t {
+			early[v.Start] = start
+		}
+		if t, ok := late[v.Start]; !ok || start > t {
+			late[v.Start] = start
+		}
+	}
+
+	// earliest and latest departures
+	earliest := func(node int) int {
+		if start, ok := early[node]; ok {
+			return start
+		}
+		return tsp.depart(node, tsp.ready(node))
+	}
+	latest := func(node int) int {
+		if start, ok := late[node]; ok {
+			return start
+		}
+		if tsp.due(node) == noDue {
+			return noDue
+		}
+		return tsp.dueDate[node] + tsp.serviceTime[node]
+	}
+
+	// windows of the node are clipped, the bounds move out of the gaps, soft
+	// bounds which do not bind are kept
+	raise := func(node, ready int) {
+		if ready = tsp.start(node, ready); tsp.waits(node) && ready > tsp.readyTime[node] {
+			r.ReadyTightened += ready - tsp.readyTime[node]
+			tsp.readyTime[node] = ready
+			tsp.clipWindows(node)
+			changed = true
+		}
+	}
+	lower := func(node, due int) {
+		if due = tsp.latestStart(node, due); tsp.due(node) != noDue && due < tsp.dueDate[node] {
+			r.DueTightened += tsp.dueDate[node] - due
+			tsp.dueDate[node] = due
+			tsp.clipWindows(node)
+			changed = true
+		}
+	}
+
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			if tsp.arcs[i][j] && earliest(i)+tsp.travel(i, j) > tsp.due(j) {
+				tsp.arcs[i][j] = false
+				changed = true
+			}
+		}
+	}
+
+	for j := 0; j < n; j++ {
+		if tsp.isStart(j) {
+			continue
+		}
+
+		first, last, reachable := noDue, 0, false
+		for i := 0; i < n; i++ {
+			if !tsp.arcs[i][j] {
+				continue
+			}
+			reachable = true
+			if arrival := earliest(i) + tsp.travel(i, j); arrival < first {
+				first = arrival
+			}
+			if arrival := latest(i) + tsp.travel(i, j); arrival > last {
+				last = arrival
+			}
+		}
+
+		if !reachable {
+			// optional requests are rejected instead
+			if tsp.isOptional(j) {
+				continue
+			}
+			r.Infeasible = append(r.Infeasible, j)
+			return
+		}
+
+		raise(j, first)
+		if last < tsp.ready(j) {
+			last = tsp.ready(j)
+		}
+		lower(j, last)
+	}
+
+	for first, followers := range tsp.after {
+		if len(followers) == 0 || tsp.isStart(first) {
+			continue
+		}
+
+		// the node is left for a successor before the followers
+		successor, shortest, bounded := 0, noDue, true
+		for k := 0; k < n; k++ {
+			if !tsp.arcs[first][k] {
+				continue
+			}
+			bounded = bounded && tsp.due(k) != noDue
+			if due := tsp.due(k) - tsp.travel(first, k); due > successor {
+				successor = due
+			}
+			if tsp.travel(first, k) < shortest {
+				shortest = tsp.travel(first, k)
+			}
+		}
+		if bounded && shortest < noDue {
+			lower(first, successor-tsp.serviceTime[first])
+		}
+
+		for _, second := range followers {
+			if due := tsp.due(second); due != noDue && shortest < noDue {
+				lower(first, due-shortest-tsp.serviceTime[first])
+			}
+
+			// the follower is reached from a node visited after the node
+			in := noDue
+			for k := 0; k < n; k++ {
+				if !tsp.isStart(k) && tsp.arcs[k][second] && tsp.travel(k, second) < in {
+					in = tsp.travel(k, second)
+				}
+			}
+			if in < noDue {
+				raise(second, earliest(first)+in)
+			}
+		}
+	}
+
+	for node := 0; node < n; node++ {
+		if tsp.ready(node) > tsp.due(node) && !tsp.isOptional(node) {
+			r.Infeasible = append(r.Infeasible, node)
+		}
+	}
+	return
+}
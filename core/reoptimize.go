@@ -0,0 +1,187 @@
+package core
+
+import (
+	"fmt"
+)
+
+// Reoptimize re-solves the route of s whose first executed nodes have been
+// served, the vehicle leaves the last of them at now. The executed prefix is
+// kept and the rest of the route is rebuilt by the construction and the
+// optimization. The departures from the executed pickups are taken from the
+// schedule of s.
+func (c Core) Reoptimize(s *Solution, now, executed int) (*Solution, error) {
+	tsp, nodes, err := s.remainder(now, executed)
+	if err != nil {
+		return nil, err
+	}
+	if len(nodes) <= 2 {
+		// a single node left has no other order
+		return s.Copy(), nil
+	}
+
+	rest, err := c.Process(tsp)
+	if rest == nil {
+		return nil, err
+	}
+
+	return s.withRest(rest, nodes, executed), err
+}
+
+// withRest returns copy of s with the route after the first executed nodes
+// taken from the route of their remainder
+func (s *Solution) withRest(rest *Solution, nodes []int, executed int) *Solution {
+	route := make([]int, executed, len(s.route))
+	copy(route, s.route[:executed])
+	for _, node := range rest.route[1:] {
+		route = append(route, nodes[node])
+	}
+
+	x := s.Copy()
+	x.route = route
+	return x
+}
+
+// remainder returns the instance of the nodes of the route after the executed
+// prefix starting at its last node at now and of the unserved requests, and
+// the nodes of s by the nodes of the instance. The deliveries of the pairs
+// picked up in the prefix are on board. The break is left out if the driver
+// has rested in the prefix. The windows are taken from the instance before
+// preprocessing. Returns error if the vehicle leaves the last executed node
+// before its schedule allows.
+func (s *Solution) remainder(now, executed int) (*PDPTW, []int, error) {
+	if executed < 1 || executed > len(s.route) || now < 0 {
+		return nil, nil, fmt.Errorf("%w: %d nodes executed at %d", ErrExecuted, executed, now)
+	}
+	schedule := s.Schedule()
+	if departure := schedule[executed-1].Departure; now < departure {
+		return nil, nil, fmt.Errorf("%w: node %d left at %d before its departure %d",
+			ErrExecuted, s.route[executed-1], now, departure)
+	}
+
+	nodes := append([]int(nil), s.route[executed-1:]...)
+	for _, node := range s.Unserved() {
+		nodes = append(nodes, s.tsp.requestOf(node)...)
+	}
+	index := make(map[int]int, len(nodes))
+	for i, node := range nodes {
+		index[node] = i
+	}
+
+	v := s.tsp.vehicleAt(s.vehicle)
+	carrying := schedule[executed-1].Load
+
+	tsp := newInstance(s.tsp.name, len(nodes))
+	tsp.capacity = v.Capacity
+	tsp.carrying = carrying
+	tsp.traveled = now
+	tsp.loading = s.tsp.loading
+	if limit := s.tsp.maxDuration; limit > 0 {
+		// the duration already driven counts
+		if tsp.maxDuration = limit - now + v.Ready; tsp.maxDuration <= 0 {
+			return nil, nil, fmt.Errorf("%w: duration %d exceeded at %d", ErrShift, limit, now)
+		}
+	}
+	tsp.rest = s.tsp.Break()
+	for _, stop := range schedule[:executed] {
+		if stop.Rest > 0 {
+			tsp.rest = nil
+		}
+	}
+	tsp.matrix = newTravelMatrix(len(nodes))
+	for i, from := range nodes {
+		for j, to := range nodes {
+			tsp.matrix.set(i, j, s.tsp.travel(from, to))
+		}
+	}
+
+	if end, ok := index[v.End]; ok {
+		tsp.endNode = end
+	}
+	if s.tsp.fleet != nil {
+		fleet := []Vehicle{{Capacity: v.Capacity, End: tsp.endNode, Ready: now, Due: v.Due,
+			Carrying: carrying, Cost: v.Cost}}
+		if err := tsp.SetFleet(fleet); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	// departures from the pickups of the items on board
+	departure := make(map[int]int)
+	for i, stop := range schedule[:executed] {
+		if delivery := s.tsp.deliveryOf(stop.Node); delivery >= 0 && index[delivery] > 0 {
+			departure[stop.Node] = stop.Departure
+			tsp.onboard = append(tsp.onboard, index[delivery])
+		}
+		if i == executed-1 {
+			departure[stop.Node] = now
+		}
+	}
+
+	for i, node := range nodes[1:] {
+		i++
+		tsp.serviceTime[i] = s.tsp.serviceTime[node]
+		windows := s.tsp.original().Windows(node)
+
+		pickup, ok := s.tsp.precedence[node]
+		switch {
+		case ok && pickup >= 0 && index[pickup] > 0:
+			p := index[pickup]
+			tsp.setPair(p, i, s.tsp.demand(pickup), tsp.readyTime[p], tsp.dueDate[p], 0, 0)
+			if err := tsp.SetMaxRideTime(p, i, s.tsp.maxRide[node]); err != nil {
+				return nil, nil, err
+			}
+		case ok && pickup >= 0:
+			tsp.setSingle(i, s.tsp.demand(node), 0, 0)
+			if limit := s.tsp.maxRide[node]; limit > 0 {
+				if windows = windowsUntil(windows, departure[pickup]+limit); windows == nil {
+					return nil, nil, fmt.Errorf("%w: delivery %d cannot keep the ride time %d at %d",
+						ErrRideTime, node, limit, now)
+				}
+			}
+		case ok:
+			tsp.setSingle(i, s.tsp.demand(node), 0, 0)
+		default:
+			// pickups get their pair with the delivery
+			if demand, ok := s.tsp.demands[node]; ok {
+				tsp.demands[i] = demand
+			}
+		}
+
+		if err := tsp.SetWindows(i, windows); err != nil {
+			return nil, nil, err
+		}
+		if costs, ok := s.tsp.softCosts[node]; ok {
+			if err := tsp.SetSoftWindow(i, costs); err != nil {
+				return nil, nil, err
+			}
+		}
+		if cost := s.tsp.RejectionCost(node); cost > 0 {
+			if err := tsp.SetRejectionCost(i, cost); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+
+	for _, rule := range s.tsp.precedences(false) {
+		first, ok := index[rule[0]]
+		second, remains := index[rule[1]]
+		if ok && remains && first > 0 {
+			tsp.addPrecedence(first, second)
+		}
+	}
+	return tsp, nodes, nil
+}
+
+// windowsUntil returns the windows cut at due, nil if no window starts by due
+func windowsUntil(windows []Window, due int) (cut []Window) {
+	for _, w := range windows {
+		if w.Ready > due {
+			break
+		}
+		if w.Due == 0 || w.Due > due {
+			w.Due = due
+		}
+		cut = append(cut, w)
+	}
+	return
+}
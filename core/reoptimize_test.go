@@ -0,0 +1,48 @@
+package core
+
+import (
+	"errors"
+	"sort"
+	"testing"
+)
+
+func TestReoptimizeKeepsExecutedPrefix(t *testing.T) {
+	c := testCore()
+	tsp := parseTestInstance(t)
+	s := NewSolution(tsp, []int{0, 3, 1, 4, 2})
+
+	for executed := 1; executed <= len(s.route); executed++ {
+		now := s.Schedule()[executed-1].Departure + 1
+		x, err := c.Reoptimize(&s, now, executed)
+		if err != nil {
+			t.Fatal(executed, err)
+		}
+
+		for i := 0; i < executed; i++ {
+			if x.route[i] != s.route[i] {
+				t.Fatalf("%d executed: route %v does not start with %v", executed, x.route,
+					s.route[:executed])
+			}
+		}
+		nodes := append([]int(nil), x.route...)
+		sort.Ints(nodes)
+		for node := range nodes {
+			if nodes[node] != node {
+				t.Fatalf("%d executed: route %v does not serve all nodes", executed, x.route)
+			}
+		}
+		if !x.IsFeasible() {
+			t.Fatalf("%d executed: route %v is infeasible", executed, x.route)
+		}
+	}
+}
+
+func TestReoptimizeBeforeDeparture(t *testing.T) {
+	tsp := parseTestInstance(t)
+	s := NewSolution(tsp, []int{0, 3, 1, 4, 2})
+
+	now := s.Schedule()[2].Departure - 1
+	if _, err := testCore().Reoptimize(&s, now, 3); !errors.Is(err, ErrExecuted) {
+		t.Fatalf("got %v, want executed prefix error", err)
+	}
+}
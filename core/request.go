@@ -0,0 +1,277 @@
+package core
+
+import (
+	"fmt"
+	"time"
+)
+
+// Request is a new pickup and delivery pair arriving while the route is
+// driven, MaxRideTime limits its ride time unless it is zero
+type Request struct {
+	Demand      Load
+	Pickup      RequestNode
+	Delivery    RequestNode
+	MaxRideTime int
+}
+
+// RequestNode is a node of a new request, nil Windows means no time window.
+// Instances with coordinates locate the node at Point, the others take To and
+// From, the travel times to and from the nodes of the instance followed by the
+// pickups and deliveries of all the requests inserted together. The travel
+// times between the pickup and the delivery are taken from the pickup.
+type RequestNode struct {
+	Windows []Window
+	Service int
+	Point   Point
+	To      []int
+	From    []int
+}
+
+// windows returns time windows of the node
+func (n RequestNode) windows() []Window {
+	if len(n.Windows) == 0 {
+		return []Window{{}}
+	}
+	return n.Windows
+}
+
+// InsertRequests adds the requests to a copy of the instance of s before
+// preprocessing and inserts their pairs one by one at the cheapest feasible
+// positions after the first served nodes of the route. The requests which
+// cannot be served are left out of the instance and their indexes are
+// returned. With search the route after the served nodes is then improved by
+// the local search of the optimization. The route and the instance of s are
+// kept.
+func (c Core) InsertRequests(s *Solution, served int, reqs []Request, search bool) (*Solution, []int, error) {
+	if served < 1 || served > len(s.route) {
+		return nil, nil, fmt.Errorf("%w: %d nodes served", ErrExecuted, served)
+	}
+	tsp := s.tsp.original().clone()
+	tsp.soft = s.tsp.soft
+
+	numNodes := tsp.numNodes
+	for k, r := range reqs {
+		if err := tsp.checkRequest(r, numNodes+2*k, len(reqs)); err != nil {
+			return nil, nil, fmt.Errorf("request %d: %w", k, err)
+		}
+	}
+
+	defaults := SoftWindow{}
+	if c.soft != nil {
+		defaults = *c.soft
+	}
+
+	// nodes of the instance by the positions of the travel times of requests,
+	// -1 for the requests not added
+	nodes := make([]int, numNodes+2*len(reqs))
+	for i := range nodes {
+		nodes[i] = -1
+		if i < numNodes {
+			nodes[i] = i
+		}
+	}
+
+	x := s.Copy()
+	x.tsp = tsp
+	var rejected []int
+	for k, r := range reqs {
+		previous := *tsp
+		position := numNodes + 2*k
+		pickup, delivery, err := tsp.addRequest(r, nodes, position, defaults)
+		if err != nil {
+			return nil, nil, fmt.Errorf("request %d: %w", k, err)
+		}
+
+		next, ok := x.insertPair(pickup, delivery, served, c.planObjective)
+		if !ok {
+			next = x.insertNodes([]int{pickup, delivery}, served, c.planObjective)
+		}
+		if next != nil {
+			x = next
+			continue
+		}
+
+		tsp.removeRequest(previous, pickup, delivery)
+		nodes[position], nodes[position+1] = -1, -1
+		rejected = append(rejected, k)
+	}
+
+	if search && len(rejected) < len(reqs) {
+		x = c.improveRest(x, served)
+	}
+	return x, rejected, nil
+}
+
+// checkRequest returns error if the request at position of the travel times
+// cannot be added to the instance with the given number of requests
+func (tsp *PDPTW) checkRequest(r Request, position, requests int) error {
+	if len(r.Demand) != tsp.Dimensions() || r.Demand.negative() {
+		return fmt.Errorf("%w: demand %v of %d dimensions", ErrDimension, r.Demand,
+			tsp.Dimensions())
+	}
+	if r.MaxRideTime < 0 {
+		return fmt.Errorf("%w: limit %d", ErrRideTime, r.MaxRideTime)
+	}
+
+	size := tsp.numNodes + 2*requests
+	for i, n := range []RequestNode{r.Pickup, r.Delivery} {
+		if err := checkWindows(position+i, n.windows()); err != nil {
+			return err
+		}
+		if n.Service < 0 {
+			return fmt.Errorf("%w: service time %d", ErrTaskFormat, n.Service)
+		}
+		if tsp.coords != nil {
+			continue
+		}
+
+		if len(n.To) != size || len(n.From) != size {
+			return fmt.Errorf("%w: %d and %d travel times of %d nodes", ErrMissingMatrix,
+				len(n.To), len(n.From), size)
+		}
+		for _, times := range [][]int{n.To, n.From} {
+			for _, value := range times {
+				if value < 0 || value > maxTravel {
+					return fmt.Errorf("%w: %d", ErrTravelRange, value)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// addRequest adds the checked request at position of the travel times as a
+// new pair, nodes are updated with it. The arcs eliminated by Preprocess are
+// dropped. Returns error if a distance to the points of the pair does not fit
+// into the travel matrix.
+func (tsp *PDPTW) addRequest(r Request, nodes []int, position int, defaults SoftWindow) (pickup, delivery int, err error) {
+	n := tsp.numNodes
+	pickup, delivery = n, n+1
+	nodes[position], nodes[position+1] = pickup, delivery
+
+	tsp.numNodes = n + 2
+	tsp.readyTime = append(tsp.readyTime[:n:n], 0, 0)
+	tsp.dueDate = append(tsp.dueDate[:n:n], 0, 0)
+	tsp.serviceTime = append(tsp.serviceTime[:n:n], r.Pickup.Service, r.Delivery.Service)
+	tsp.before = append(tsp.before[:n:n], nil, nil)
+	tsp.after = append(tsp.after[:n:n], nil, nil)
+	if tsp.windows != nil {
+		tsp.windows = append(tsp.windows[:n:n], nil, nil)
+	}
+	tsp.arcs = nil
+
+	matrix := newTravelMatrix(n + 2)
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			matrix.set(i, j, tsp.travel(i, j))
+		}
+	}
+	if tsp.coords != nil {
+		tsp.coords = append(tsp.coords[:n:n], r.Pickup.Point, r.Delivery.Point)
+		for _, node := range []int{pickup, delivery} {
+			for i := range tsp.coords {
+				to := tsp.metric.Distance(tsp.coords[node], tsp.coords[i])
+				from := tsp.metric.Distance(tsp.coords[i], tsp.coords[node])
+				if err = checkTravel(to); err == nil {
+					err = checkTravel(from)
+				}
+				if err != nil {
+					return
+				}
+				matrix.set(node, i, to)
+				matrix.set(i, node, from)
+			}
+		}
+	} else {
+		// the pickup is the last to set the times between the pair
+		for _, node := range []int{delivery, pickup} {
+			times := r.Pickup
+			if node == delivery {
+				times = r.Delivery
+			}
+			for i, other := range nodes {
+				if other >= 0 && other != node {
+					matrix.set(node, other, times.To[i])
+					matrix.set(other, node, times.From[i])
+				}
+			}
+		}
+	}
+	tsp.matrix = matrix
+
+	if tsp.precedence == nil {
+		tsp.precedence = make(map[int]int)
+	}
+	tsp.precedence[delivery] = pickup
+	tsp.addPrecedence(pickup, delivery)
+	tsp.demands[pickup] = r.Demand
+	tsp.demands[delivery] = r.Demand.negated()
+	tsp.setWindows(pickup, r.Pickup.windows())
+	tsp.setWindows(delivery, r.Delivery.windows())
+	if r.MaxRideTime > 0 {
+		if tsp.maxRide == nil {
+			tsp.maxRide = make(map[int]int)
+		}
+		tsp.maxRide[delivery] = r.MaxRideTime
+	}
+
+	if tsp.soft != nil {
+		tsp.soft = &softWindows{
+			windows: append(tsp.soft.windows[:n:n], tsp.Windows(pickup), tsp.Windows(delivery)),
+			costs:   append(tsp.soft.costs[:n:n], defaults, defaults),
+		}
+	}
+	return
+}
+
+// removeRequest returns the instance to its previous state before the pair
+// was added
+func (tsp *PDPTW) removeRequest(previous PDPTW, pickup, delivery int) {
+	*tsp = previous
+	for _, node := range []int{pickup, delivery} {
+		delete(tsp.precedence, node)
+		delete(tsp.demands, node)
+		delete(tsp.maxRide, node)
+	}
+}
+
+// improveRest runs the local search over the route after the first served
+// nodes, the served nodes leave at the times of the schedule. The search is
+// repeated while it improves the route, at most IterMax times and until
+// MaxTime.
+func (c Core) improveRest(s *Solution, served int) *Solution {
+	now := s.Schedule()[served-1].Departure
+	tsp, nodes, err := s.remainder(now, served)
+	if err != nil || len(nodes) <= 3 {
+		return s
+	}
+	if tsp, err = c.prepare(tsp); err != nil {
+		return s
+	}
+
+	// the unserved requests follow the nodes of the route
+	rest := &Solution{route: make([]int, len(s.route)-served+1), tsp: tsp}
+	for i := range rest.route {
+		rest.route[i] = i
+	}
+
+	timeout := time.After(c.common.MaxTime * time.Second)
+	for i := 0; i < c.common.IterMax; i++ {
+		channel := make(chan *Solution, 1)
+		go func(x *Solution) {
+			c.search.process(x)
+			channel <- x
+		}(rest.Copy())
+
+		select {
+		case x := <-channel:
+			if c.objective.get(x) >= c.objective.get(rest) {
+				return s.withRest(rest, nodes, served)
+			}
+			rest = x
+		case <-timeout:
+			return s.withRest(rest, nodes, served)
+		}
+	}
+	return s.withRest(rest, nodes, served)
+}
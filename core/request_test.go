@@ -0,0 +1,59 @@
+package core
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/mitas1/psa-core/config"
+)
+
+// testCore returns core with short searches of the configuration of
+// config.yaml
+func testCore() *Core {
+	cfg := &config.Config{}
+	cfg.Common.IterMax = 1
+	cfg.Common.MaxTime = 5
+	cfg.Construction = config.Construction{Strategy: "random", LevelMax: 10,
+		Penalty: benchmarkPenalty}
+	cfg.Optimization.VNS = config.VNS{IterMax: 2, LevelMax: 10, LocalSearch: config.VND}
+	return NewCore(cfg)
+}
+
+// lineInstance returns instance of a single pair on a line, the pickup at 10
+// and the delivery at 20 from the start node
+func lineInstance(t *testing.T) *PDPTW {
+	points := []Point{{X: 0, Y: 0}, {X: 10, Y: 0}, {X: 20, Y: 0}}
+	tsp, err := NewInstanceFromCoordinates(0, 10, 0, 0, []int{0, 0, 0}, []int{0, 0, 0},
+		map[int]int{1: 1, 2: -1}, map[int]int{0: -1, 1: -1, 2: 1}, points, Euclidean{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &tsp
+}
+
+func TestInsertRequestsRejectsLateRequest(t *testing.T) {
+	c := testCore()
+	s, err := c.Process(lineInstance(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	near := Request{Demand: Load{1},
+		Pickup:   RequestNode{Point: Point{X: 15}},
+		Delivery: RequestNode{Point: Point{X: 5}}}
+	// the pickup closes long before the vehicle gets there
+	late := Request{Demand: Load{1},
+		Pickup:   RequestNode{Point: Point{X: 1000}, Windows: []Window{{Ready: 0, Due: 5}}},
+		Delivery: RequestNode{Point: Point{X: 5}}}
+
+	x, rejected, err := c.InsertRequests(s, 1, []Request{near, late}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(rejected, []int{1}) {
+		t.Fatalf("rejected %v, want [1]", rejected)
+	}
+	if len(x.route) != len(s.route)+2 || !x.IsFeasible() {
+		t.Fatalf("route %v is not the feasible route with the near request", x.route)
+	}
+}
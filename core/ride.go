@@ -0,0 +1,57 @@
+package core
+
+import (
+	"fmt"
+)
+
+// SetMaxRideTime limits the ride time of the pair, the time from the
+// departure from the pickup to the start of the service at the delivery. Zero
+// removes the limit.
+func (tsp *PDPTW) SetMaxRideTime(pickup, delivery, limit int) error {
+	for _, node := range []int{pickup, delivery} {
+		if node < 0 || node >= tsp.numNodes {
+			return fmt.Errorf("%w: %d", ErrNodeRange, node)
+		}
+	}
+	if p, ok := tsp.precedence[delivery]; !ok || p != pickup {
+		return fmt.Errorf("%w: %d and %d are not a pair", ErrRideTime, pickup, delivery)
+	}
+	if limit < 0 {
+		return fmt.Errorf("%w: pair %d and %d has limit %d", ErrRideTime, pickup, delivery, limit)
+	}
+
+	if limit == 0 {
+		delete(tsp.maxRide, delivery)
+		return nil
+	}
+	if tsp.maxRide == nil {
+		tsp.maxRide = make(map[int]int)
+	}
+	tsp.maxRide[delivery] = limit
+	return nil
+}
+
+// MaxRideTime returns the limit of the ride time of the pair with the
+// delivery, zero if it has none
+func (tsp *PDPTW) MaxRideTime(delivery int) int {
+	return tsp.maxRide[delivery]
+}
+
+// rideExcess returns the sum of the ride times above their limits over the
+// pairs of the route
+func (s *Solution) rideExcess() (excess int) {
+	if len(s.tsp.maxRide) == 0 {
+		return 0
+	}
+
+	departure := make(map[int]int)
+	for _, stop := range s.Schedule() {
+		if limit, ok := s.tsp.maxRide[stop.Node]; ok {
+			if d, ok := departure[s.tsp.precedence[stop.Node]]; ok && stop.Start-d > limit {
+				excess += stop.Start - d - limit
+			}
+		}
+		departure[stop.Node] = stop.Departure
+	}
+	return
+}
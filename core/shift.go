@@ -0,0 +1,181 @@
+package core
+
+import (
+	"fmt"
+)
+
+// Break is the rest of the driver lasting Duration which starts between Ready
+// and Due, routes reaching their last node by Due need no break
+type Break struct {
+	Ready    int
+	Due      int
+	Duration int
+}
+
+// SetMaxDuration limits the duration of every route, the time from the ready
+// time of its vehicle to the arrival at its last node including the waiting
+// and the break. Zero removes the limit.
+func (tsp *PDPTW) SetMaxDuration(limit int) error {
+	if limit < 0 {
+		return fmt.Errorf("%w: duration %d", ErrShift, limit)
+	}
+	tsp.maxDuration = limit
+	return nil
+}
+
+// MaxDuration returns the limit of the route duration, zero if it has none
+func (tsp *PDPTW) MaxDuration() int {
+	return tsp.maxDuration
+}
+
+// SetBreak makes the driver of every route rest after the service at one of
+// its nodes, nil removes the break
+func (tsp *PDPTW) SetBreak(b *Break) error {
+	if b == nil {
+		tsp.rest = nil
+		return nil
+	}
+	if b.Duration <= 0 || b.Ready < 0 || b.Due < b.Ready {
+		return fmt.Errorf("%w: break of %d in [%d, %d]", ErrShift, b.Duration, b.Ready, b.Due)
+	}
+	rest := *b
+	tsp.rest = &rest
+	return nil
+}
+
+// Break returns copy of the break of the drivers, nil if they have none
+func (tsp *PDPTW) Break() *Break {
+	if tsp.rest == nil {
+		return nil
+	}
+	rest := *tsp.rest
+	return &rest
+}
+
+// schedule returns the schedule of the route with the break taken after the
+// node at position rest, -1 for none
+func (s *Solution) schedule(rest int) []Stop {
+	schedule := make([]Stop, len(s.route))
+
+	v := s.tsp.vehicleAt(s.vehicle)
+	arrival := v.Ready
+	load := v.Carrying.copy()
+
+	for i, node := range s.route {
+		if i > 0 {
+			arrival = schedule[i-1].Departure + s.tsp.travel(s.route[i-1], node)
+		}
+
+		start := s.tsp.start(node, arrival)
+
+		load.add(s.tsp.demands[node])
+
+		schedule[i] = Stop{
+			Node:      node,
+			Arrival:   arrival,
+			Start:     start,
+			Departure: start + s.tsp.serviceTime[node],
+			Load:      load.copy(),
+		}
+
+		if i == rest {
+			// the driver waits for the window of the break
+			if schedule[i].Departure < s.tsp.rest.Ready {
+				schedule[i].Departure = s.tsp.rest.Ready
+			}
+			schedule[i].Departure += s.tsp.rest.Duration
+			schedule[i].Rest = s.tsp.rest.Duration
+		}
+	}
+	return schedule
+}
+
+// restAfter returns the position of the node after which the driver rests
+// given the schedule without the break, -1 if the route needs no break. The
+// driver rests after the first node from which the break delays nothing,
+// otherwise after the last node from which the break still starts by its due.
+func (s *Solution) restAfter(free []Stop) int {
+	b, n := s.tsp.rest, len(free)
+	if b == nil || n < 2 || free[n-1].Arrival <= b.Due {
+		return -1
+	}
+
+	for i := 0; i < n-2; i++ {
+		next := free[i+1]
+		if next.Departure > b.Due {
+			return i
+		}
+
+		start := free[i].Departure
+		if start < b.Ready {
+			start = b.Ready
+		}
+		arrival := start + b.Duration + s.tsp.travel(free[i].Node, next.Node)
+		if s.tsp.start(next.Node, arrival) == next.Start {
+			return i
+		}
+	}
+	// the break precedes the last node
+	return n - 2
+}
+
+// restDelay returns the delay of the arrival at the last node caused by the
+// break
+func (s *Solution) restDelay() int {
+	if s.tsp.rest == nil {
+		return 0
+	}
+
+	free := s.schedule(-1)
+	after := s.restAfter(free)
+	if after < 0 {
+		return 0
+	}
+	n := len(free)
+	return s.schedule(after)[n-1].Arrival - free[n-1].Arrival
+}
+
+// shiftExcess returns the time units by which the route exceeds the maximum
+// duration, starts the break after its due and misses the due dates and the
+// end of the shift for the delay of the break
+func (s *Solution) shiftExcess() (excess int) {
+	if s.tsp.rest == nil && s.tsp.maxDuration == 0 {
+		return 0
+	}
+
+	v := s.tsp.vehicleAt(s.vehicle)
+	free := s.schedule(-1)
+	schedule := free
+	n := len(free)
+
+	if after := s.restAfter(free); after >= 0 {
+		schedule = s.schedule(after)
+
+		b := s.tsp.rest
+		if start := schedule[after].Departure - b.Duration; start > b.Due {
+			excess += start - b.Due
+		}
+
+		// the nodes before the break are checked without it
+		for i := after + 1; i < n; i++ {
+			due := s.tsp.due(schedule[i].Node)
+			excess += overrun(schedule[i].Start, due) - overrun(free[i].Start, due)
+		}
+		if v.Due != 0 {
+			excess += overrun(schedule[n-1].Arrival, v.Due) - overrun(free[n-1].Arrival, v.Due)
+		}
+	}
+
+	if limit := s.tsp.maxDuration; limit > 0 {
+		excess += overrun(schedule[n-1].Arrival-v.Ready, limit)
+	}
+	return
+}
+
+// overrun returns how much t exceeds due
+func overrun(t, due int) int {
+	if t > due {
+		return t - due
+	}
+	return 0
+}
@@ -0,0 +1,44 @@
+package core
+
+import "testing"
+
+func TestShiftFeasibility(t *testing.T) {
+	// the route reaches its last node at 30
+	route := []int{0, 1, 3, 4, 2}
+
+	tests := []struct {
+		directives []string
+		feasible   bool
+	}{
+		{[]string{"duration 30"}, true},
+		{[]string{"duration 29"}, false},
+		// the break is taken at the start node
+		{[]string{"break 0 10 5"}, true},
+		{[]string{"break 0 10 5", "duration 34"}, false},
+		{[]string{"break 0 10 5", "duration 35"}, true},
+		// the route ends before the break is due
+		{[]string{"break 0 40 5", "duration 30"}, true},
+	}
+
+	for _, test := range tests {
+		tsp := parseTestInstance(t, test.directives...)
+		s := NewSolution(tsp, route)
+
+		if s.IsFeasible() != test.feasible {
+			t.Errorf("%q: feasible %v, want %v", test.directives, s.IsFeasible(), test.feasible)
+		}
+		if (s.shiftExcess() == 0) != test.feasible {
+			t.Errorf("%q: excess %d", test.directives, s.shiftExcess())
+		}
+	}
+}
+
+func TestProcessKeepsDuration(t *testing.T) {
+	s, err := testCore().Process(parseTestInstance(t, "break 0 10 5", "duration 35"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !s.IsFeasible() || s.shiftExcess() != 0 {
+		t.Fatalf("route %v exceeds the shift", s.route)
+	}
+}
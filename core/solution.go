@@ -23,6 +23,20 @@ type Solution struct {
 	route []int
 	nodes map[int]bool
 	tsp   *PDPTW
+	// vehicle is the index of the vehicle serving the route
+	vehicle int
+}
+
+// Stop is a visit of a node in the schedule of a solution, Load is the load
+// of the vehicle after the service. Rest is the length of the break of the
+// driver taken before the departure, it starts at Departure minus Rest.
+type Stop struct {
+	Node      int  `json:"node"`
+	Arrival   int  `json:"arrival"`
+	Start     int  `json:"start"`
+	Departure int  `json:"departure"`
+	Load      Load `json:"load"`
+	Rest      int  `json:"rest,omitempty"`
 }
 
 // NewSolution returns a new instance of the Solution struct
@@ -62,72 +76,109 @@ func (s *Solution) WriteToFile(dir, name string) {
 	}
 }
 
+// startTime returns start of the service at the start node, the vehicle
+// waits there for the window of the node like at any other node
+func (s *Solution) startTime() int {
+	return s.tsp.start(s.route[0], s.tsp.vehicleAt(s.vehicle).Ready)
+}
+
 // IsFeasible checks if solution is feasible
 func (s *Solution) IsFeasible() bool {
-	traveled := s.tsp.traveled
-	carrying := s.tsp.carrying
+	v := s.tsp.vehicleAt(s.vehicle)
+	traveled := s.startTime()
+	carrying := v.Carrying.copy()
+	arrival := traveled
+	position := s.positions()
 
-	for i := 1; i < s.tsp.numNodes; i++ {
-		traveled += s.tsp.matrix[s.route[i-1]][s.route[i]]
-		carrying += s.tsp.demands[s.route[i-1]]
+	for i := 1; i < len(s.route); i++ {
+		traveled += s.tsp.serviceTime[s.route[i-1]] + s.tsp.travel(s.route[i-1], s.route[i])
+		carrying.add(s.tsp.demands[s.route[i-1]])
+		arrival = traveled
 
 		// wait to ready to time
-		if traveled < s.tsp.readyTime[s.route[i]] {
-			traveled = s.tsp.readyTime[s.route[i]]
-		}
+		traveled = s.tsp.start(s.route[i], traveled)
 
-		if carrying > s.tsp.capacity {
+		if carrying.exceeds(v.Capacity) {
 			return false
 		}
 
-		if value, ok := s.tsp.precedence[s.route[i]]; ok {
-			if i <= utils.IndexOf(value, s.route) {
+		for _, node := range s.tsp.before[s.route[i]] {
+			if i <= position[node] {
 				return false
 			}
 		}
 
-		if s.tsp.dueDate[s.route[i]] != 0 && s.tsp.dueDate[s.route[i]] < traveled {
+		if s.tsp.due(s.route[i]) < traveled {
 			return false
 		}
 	}
 
 	i := len(s.route) - 1
 
-	if carrying+s.tsp.demands[s.route[i]] != 0 {
+	if !carrying.cancels(s.tsp.demands[s.route[i]]) {
 		return false
 	}
 
-	return true
+	if v.End >= 0 && s.route[i] != v.End {
+		return false
+	}
+
+	if !s.meetsRouteRules() {
+		return false
+	}
+
+	return s.inShift(arrival)
+}
+
+// movable returns position past the last node which may be moved, the end
+// node stays at the end of the route
+func (s *Solution) movable() int {
+	if s.tsp.vehicleAt(s.vehicle).End >= 0 {
+		return len(s.route) - 1
+	}
+	return len(s.route)
+}
+
+// capacity returns capacity of the vehicle serving the route
+func (s *Solution) capacity() Load {
+	return s.tsp.vehicleAt(s.vehicle).Capacity
+}
+
+// inShift returns whether the vehicle arriving at the last node of the route
+// at arrival keeps its shift
+func (s *Solution) inShift(arrival int) bool {
+	due := s.tsp.vehicleAt(s.vehicle).Due
+	return due == 0 || arrival <= due
 }
 
-func (s *Solution) isFeasibleEdge(i, j int, sum, carrying *int) bool {
+// isFeasibleEdge adds the arc from i-th to j-th node of the route to the
+// arrival sum and carrying updated in place
+func (s *Solution) isFeasibleEdge(i, j int, sum *int, carrying Load) bool {
 	n1 := s.route[i]
 	n2 := s.route[j]
 
-	if s.tsp.readyTime[n1] > *sum {
-		*sum = s.tsp.readyTime[n1]
+	if !s.tsp.hasArc(n1, n2) {
+		return false
 	}
 
-	*sum += s.tsp.matrix[n1][n2]
-	*carrying += s.tsp.demands[n2]
+	*sum = s.tsp.depart(n1, *sum) + s.tsp.travel(n1, n2)
+	carrying.add(s.tsp.demands[n2])
 
-	if *sum > s.tsp.dueDate[n2] || *carrying > s.tsp.capacity {
+	if *sum > s.tsp.due(n2) || carrying.exceeds(s.capacity()) {
 		return false
 	}
 	return true
 }
 
-func (s *Solution) isFeasibleRange(start, end int, sum, carrying *int) bool {
+func (s *Solution) isFeasibleRange(start, end int, sum *int, carrying Load) bool {
 	var n1, n2 int
+	capacity := s.capacity()
 	for i := start; i < end; i++ {
 		n1 = s.route[i]
 		n2 = s.route[i+1]
-		if s.tsp.readyTime[n1] > *sum {
-			*sum = s.tsp.readyTime[n1]
-		}
-		*sum += s.tsp.matrix[n1][n2]
-		*carrying += s.tsp.demands[n2]
-		if *sum > s.tsp.dueDate[n2] || *carrying > s.tsp.capacity {
+		*sum = s.tsp.depart(n1, *sum) + s.tsp.travel(n1, n2)
+		carrying.add(s.tsp.demands[n2])
+		if *sum > s.tsp.due(n2) || carrying.exceeds(capacity) {
 			return false
 		}
 	}
@@ -135,32 +186,29 @@ func (s *Solution) isFeasibleRange(start, end int, sum, carrying *int) bool {
 }
 
 func (s *Solution) getSet(setType SetType) (set []int) {
-	traveled := s.tsp.traveled
-	carrying := s.tsp.carrying
-	predViolation := false
+	v := s.tsp.vehicleAt(s.vehicle)
+	traveled := s.startTime()
+	carrying := v.Carrying.copy()
+	position := s.positions()
 
-	for i := 1; i < len(s.route); i++ {
-		traveled += s.tsp.matrix[s.route[i-1]][s.route[i]]
-		carrying += s.tsp.demands[s.route[i-1]]
-		predViolation = false
+	for i := 1; i < s.movable(); i++ {
+		traveled += s.tsp.serviceTime[s.route[i-1]] + s.tsp.travel(s.route[i-1], s.route[i])
+		carrying.add(s.tsp.demands[s.route[i-1]])
 
 		// wait to ready to time
-		if traveled < s.tsp.readyTime[s.route[i]] {
-			traveled = s.tsp.readyTime[s.route[i]]
-		}
+		traveled = s.tsp.start(s.route[i], traveled)
 
-		if value, ok := s.tsp.precedence[s.route[i]]; ok {
-			if i >= utils.IndexOf(value, s.route) {
+		predViolation := false
+		for _, node := range s.tsp.before[s.route[i]] {
+			if i <= position[node] {
 				predViolation = true
 			}
 		}
 
-		isFeasible := !predViolation || (s.tsp.dueDate[s.route[i]] != 0 &&
-			s.tsp.dueDate[s.route[i]] < traveled) || carrying > s.tsp.capacity
+		isFeasible := !predViolation && s.tsp.due(s.route[i]) >= traveled &&
+			!carrying.exceeds(v.Capacity)
 
-		if setType == FEASIBLE_SET && isFeasible {
-			set = append(set, i)
-		} else if isFeasible {
+		if isFeasible == (setType == FEASIBLE_SET) {
 			set = append(set, i)
 		}
 	}
@@ -215,39 +263,65 @@ func (s *Solution) getCurrent() int {
 func (s *Solution) TotalDistance() int {
 	total := 0
 	for i := 1; i <= len(s.route)-1; i++ {
-		total += s.tsp.matrix[s.route[i-1]][s.route[i]]
+		total += s.tsp.travel(s.route[i-1], s.route[i])
 	}
 	return total
 }
 
+// Schedule returns times of arrival, start of service and departure at every
+// node of the route, the departure follows the break of the driver if it is
+// taken at the node
+func (s *Solution) Schedule() []Stop {
+	schedule := s.schedule(-1)
+	if after := s.restAfter(schedule); after >= 0 {
+		return s.schedule(after)
+	}
+	return schedule
+}
+
+// windowCost returns the cost of the service outside of the soft windows
+func (s *Solution) windowCost() (cost int) {
+	if s.tsp.soft == nil {
+		return 0
+	}
+
+	if s.tsp.rest != nil {
+		for _, stop := range s.Schedule()[1:] {
+			cost += s.tsp.windowCost(stop.Node, stop.Start)
+		}
+		return
+	}
+
+	traveled := s.tsp.vehicleAt(s.vehicle).Ready
+	for i := 0; i < len(s.route)-1; i++ {
+		traveled = s.tsp.depart(s.route[i], traveled) + s.tsp.travel(s.route[i], s.route[i+1])
+		cost += s.tsp.windowCost(s.route[i+1], s.tsp.start(s.route[i+1], traveled))
+	}
+	return
+}
+
 func (s *Solution) MakeSpan() int {
-	traveled := s.tsp.traveled
+	traveled := s.tsp.vehicleAt(s.vehicle).Ready
 	for i := 0; i < len(s.route)-1; i++ {
-		if traveled < s.tsp.readyTime[s.route[i]] {
-			traveled = s.tsp.readyTime[s.route[i]]
-		}
-		traveled += s.tsp.matrix[s.route[i]][s.route[i+1]]
+		traveled = s.tsp.depart(s.route[i], traveled) + s.tsp.travel(s.route[i], s.route[i+1])
 	}
-	return traveled
+	return traveled + s.restDelay()
 }
 
 // Copy make a copy of Solution
 func (s Solution) Copy() *Solution {
-	route := []int{}
-
-	for i := 0; i < s.tsp.numNodes; i++ {
-		route = append(route, s.route[i])
-	}
+	route := make([]int, len(s.route))
+	copy(route, s.route)
 
-	return &Solution{route: route, tsp: s.tsp}
+	return &Solution{route: route, tsp: s.tsp, vehicle: s.vehicle}
 }
 
 func (s Solution) disturb(level int) (x *Solution) {
 	x = s.Copy()
 
 	for j := 0; j < level; j++ {
-		n1 := utils.Random(0, len(s.route)-2)
-		n2 := utils.Random(n1+2, len(s.route))
+		n1 := utils.Random(0, s.movable()-2)
+		n2 := utils.Random(n1+2, s.movable())
 		x = x.kExchange(n1, n2)
 	}
 	return x
@@ -277,51 +351,36 @@ func (s *Solution) kExchange(iaux, jaux int) *Solution {
 	return s
 }
 
-func (s *Solution) calcGlobals() (traveled []int, carrying, precedence map[int]int) {
+// calcGlobals returns arrival at every node of the route, load after the
+// service of every node and positions of the nodes
+func (s *Solution) calcGlobals() (traveled []int, carrying []Load, position []int) {
 	var n1, n2 int
 
-	_traveled := s.tsp.traveled
-	_carrying := s.tsp.carrying
+	v := s.tsp.vehicleAt(s.vehicle)
+	_traveled := s.startTime()
+	_carrying := v.Carrying.copy()
+
+	traveled = make([]int, len(s.route))
+	carrying = make([]Load, len(s.route))
+	position = s.positions()
 
-	traveled = make([]int, s.tsp.numNodes)
-	carrying = make(map[int]int)
-	precedence = make(map[int]int)
+	traveled[0] = _traveled
 
 	for i := 0; i < len(s.route)-1; i++ {
 		// traveled
 		n1 = s.route[i]
 		n2 = s.route[i+1]
 
-		if s.tsp.readyTime[n1] > _traveled {
-			_traveled = s.tsp.readyTime[n1]
-		}
-
-		_traveled += s.tsp.matrix[n1][n2]
+		_traveled = s.tsp.depart(n1, _traveled) + s.tsp.travel(n1, n2)
 
 		traveled[i+1] = _traveled
 
-		// precedence
-		if n, ok := s.tsp.precedence[n1]; ok {
-			index := utils.IndexOf(n, s.route)
-			precedence[index] = i
-			precedence[i] = index
-		} else if _, ok := precedence[i]; !ok {
-			// ignore precedence of vertex
-			precedence[i] = -1
-		}
+		_carrying.add(s.tsp.demands[n1])
 
-		_carrying += s.tsp.demands[n1]
-
-		carrying[i] = _carrying
+		carrying[i] = _carrying.copy()
 	}
-
-	i := len(s.route) - 1
-
-	n := s.tsp.precedence[s.route[i]]
-
-	index := utils.IndexOf(n, s.route)
-	precedence[i] = index
-	precedence[index] = i
+	_carrying.add(s.tsp.demands[s.route[len(s.route)-1]])
+	carrying[len(s.route)-1] = _carrying
 
 	return
 }
@@ -334,12 +393,12 @@ var (
 
 func (s *Solution) Check() bool {
 
-	if s.tsp.startNode != s.route[0] {
+	if s.tsp.vehicleAt(s.vehicle).Start != s.route[0] {
 		log.Errorf("%v: %v", "Wrong startnode!", s.route)
 		return false
 	}
 
-	if s.tsp.numNodes != len(s.route) {
+	if s.tsp.numNodes-s.unservedNodes() != len(s.route) {
 		log.Errorf("%v: %v", "numNodes are not equal to route", s.route)
 		return false
 	}
@@ -355,6 +414,21 @@ func (s *Solution) Check() bool {
 		return false
 	}
 
+	if s.rideExcess() > 0 {
+		log.Errorf("%v: %v", "Maximum ride time exceeded", s.route)
+		return false
+	}
+
+	if s.loadingViolations() > 0 {
+		log.Errorf("%v: %v", "Loading order violated", s.route)
+		return false
+	}
+
+	if s.shiftExcess() > 0 {
+		log.Errorf("%v: %v", "Maximum duration or break violated", s.route)
+		return false
+	}
+
 	if !s.IsFeasible() {
 		log.Errorf("%v: %v", "Solution is not FEASIBLE!", s.route)
 		return false
@@ -0,0 +1,198 @@
+package core
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ParseTSPTW reads TSPTW benchmark in the matrix layout used by the
+// collections of Dumas, Gendreau, Ohlmann & Thomas and others: number of
+// nodes, the travel matrix and a "ready due" line per node. Lines starting
+// with "!!" are comments, real travel times are rounded. Node 0 is the start
+// node, all other nodes are single nodes without demand. A copy of the start
+// node with the same window is added as the end node closing the tour.
+func ParseTSPTW(name string, r io.Reader) (*PDPTW, error) {
+	p := tsptwParser{lineParser: lineParser{name: name}}
+
+	if err := p.scan(r, p.parseLine); err != nil {
+		return nil, err
+	}
+
+	if p.tsp == nil {
+		return nil, &ParseError{File: p.name, Field: "header", Err: ErrHeader}
+	}
+
+	if len(p.rows) != p.size {
+		return nil, &ParseError{File: p.name, Line: p.line, Field: "matrix", Err: fmt.Errorf(
+			"%w: expected %d rows, got %d", ErrMissingMatrix, p.size, len(p.rows))}
+	}
+
+	if p.windows != p.size {
+		return nil, &ParseError{File: p.name, Line: p.line, Field: "window", Err: fmt.Errorf(
+			"%w: expected %d time windows, got %d", ErrDimension, p.size, p.windows)}
+	}
+
+	// the end node is reached like the start node
+	for i := range p.rows {
+		p.rows[i] = append(p.rows[i], p.rows[i][0])
+	}
+	p.rows = append(p.rows, append([]int{}, p.rows[0]...))
+
+	matrix, err := matrixFromRows(p.rows)
+	if err != nil {
+		return nil, &ParseError{File: p.name, Field: "matrix", Err: err}
+	}
+	p.tsp.matrix = matrix
+	p.tsp.setTSPTWEnd()
+	return p.tsp, nil
+}
+
+type tsptwParser struct {
+	lineParser
+	tsp     *PDPTW
+	size    int
+	rows    [][]int
+	windows int
+}
+
+func (p *tsptwParser) parseLine(fields []string) error {
+	if strings.HasPrefix(fields[0], "!!") {
+		return nil
+	}
+
+	values := make([]int, len(fields))
+	for i, str := range fields {
+		value, err := p.float(fmt.Sprintf("field %d", i), str)
+		if err != nil {
+			return err
+		}
+		if math.Abs(value) > maxTravel {
+			return p.errorf(fmt.Sprintf("field %d", i), fmt.Errorf("%w: %v", ErrTravelRange, value))
+		}
+		values[i] = int(math.Round(value))
+	}
+
+	switch {
+	case p.tsp == nil:
+		if len(values) != 1 || values[0] <= 0 {
+			return p.errorf("numNodes", fmt.Errorf("%w: expected number of nodes", ErrHeader))
+		}
+		p.size = values[0]
+		p.tsp = newInstance(p.name, p.size+1)
+		p.rows = make([][]int, 0, p.size+1)
+	case len(p.rows) < p.size:
+		if len(values) != p.size {
+			return p.errorf(fmt.Sprintf("matrix[%d]", len(p.rows)), fmt.Errorf(
+				"%w: expected %d columns, got %d", ErrDimension, p.size, len(values)))
+		}
+		p.rows = append(p.rows, values)
+	case p.windows < p.size:
+		if len(values) != 2 {
+			return p.errorf("window", fmt.Errorf("%w: expected \"ready due\"", ErrTaskFormat))
+		}
+		p.tsp.setTSPTWNode(p.windows, values[0], values[1])
+		p.windows++
+	default:
+		return p.errorf("", fmt.Errorf("%w: unexpected line after time windows", ErrTaskFormat))
+	}
+	return nil
+}
+
+// setTSPTWNode defines node of TSPTW, the start node has only time window
+func (tsp *PDPTW) setTSPTWNode(node, ready, due int) {
+	if node == tsp.startNode {
+		tsp.readyTime[node] = ready
+		tsp.dueDate[node] = due
+		return
+	}
+	tsp.setSingle(node, Load{0}, ready, due)
+}
+
+// setTSPTWEnd makes the last node a copy of the start node closing the tour
+func (tsp *PDPTW) setTSPTWEnd() {
+	tsp.endNode = tsp.numNodes - 1
+	tsp.setSingle(tsp.endNode, Load{0}, tsp.readyTime[tsp.startNode], tsp.dueDate[tsp.startNode])
+}
+
+var tsptwCoordsFields = []string{"id", "x", "y", "demand", "readyTime", "dueDate", "serviceTime"}
+
+// ParseTSPTWCoords reads TSPTW benchmark in the Solomon like layout of Dumas
+// and da Silva & Urrutia: a line "id x y demand ready due service" per node
+// terminated by id 999. The first node is the start node, the matrix is
+// computed by metric (Euclidean if nil) and service times are kept on nodes.
+// A copy of the start node is added as the end node closing the tour.
+func ParseTSPTWCoords(name string, r io.Reader, metric Metric) (*PDPTW, error) {
+	if metric == nil {
+		metric = Euclidean{}
+	}
+
+	p := tsptwCoordsParser{lineParser: lineParser{name: name}}
+
+	if err := p.scan(r, p.parseLine); err != nil {
+		return nil, err
+	}
+
+	if len(p.coords) == 0 {
+		return nil, &ParseError{File: p.name, Field: "node", Err: ErrUndefinedNode}
+	}
+
+	tsp := newInstance(name, len(p.coords)+1)
+
+	for node, window := range p.windows {
+		tsp.setTSPTWNode(node, window[0], window[1])
+		tsp.serviceTime[node] = p.service[node]
+	}
+	tsp.setTSPTWEnd()
+
+	if err := tsp.SetCoordinates(append(p.coords, p.coords[0]), metric); err != nil {
+		return nil, &ParseError{File: p.name, Field: "coordinates", Err: err}
+	}
+	return tsp, nil
+}
+
+type tsptwCoordsParser struct {
+	lineParser
+	done    bool
+	coords  []Point
+	windows [][2]int
+	service []int
+}
+
+func (p *tsptwCoordsParser) parseLine(fields []string) error {
+	// comments and column names
+	if p.done || strings.HasPrefix(fields[0], "!!") ||
+		unicode.IsLetter(rune(fields[0][0])) {
+		return nil
+	}
+
+	if len(fields) != len(tsptwCoordsFields) {
+		return p.errorf("node", fmt.Errorf("%w: expected %d fields, got %d",
+			ErrTaskFormat, len(tsptwCoordsFields), len(fields)))
+	}
+
+	id, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return p.errorf("id", fmt.Errorf("%w %q", ErrSyntax, fields[0]))
+	}
+
+	if id == 999 {
+		p.done = true
+		return nil
+	}
+
+	values := make([]float64, len(fields))
+	for i, str := range fields {
+		if values[i], err = p.float(tsptwCoordsFields[i], str); err != nil {
+			return err
+		}
+	}
+
+	p.coords = append(p.coords, Point{X: values[1], Y: values[2]})
+	p.windows = append(p.windows, [2]int{int(math.Round(values[4])), int(math.Round(values[5]))})
+	p.service = append(p.service, int(math.Round(values[6])))
+	return nil
+}
@@ -0,0 +1,314 @@
+package core
+
+import (
+	"fmt"
+)
+
+// Severity of an issue found by Validate
+type Severity string
+
+// Errors make the instance unsolvable or break the solver, warnings only
+// violate assumptions of some objectives or moves
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+// maxIssues of one kind listed in the report, the rest is summarized
+const maxIssues = 10
+
+// Issue is a single problem of an instance
+type Issue struct {
+	Severity Severity `json:"severity"`
+	Code     string   `json:"code"`
+	Message  string   `json:"message"`
+	Nodes    []int    `json:"nodes,omitempty"`
+}
+
+// Report lists all issues of an instance
+type Report struct {
+	Instance string  `json:"instance"`
+	Errors   int     `json:"errors"`
+	Warnings int     `json:"warnings"`
+	Issues   []Issue `json:"issues"`
+}
+
+// HasErrors returns whether any issue is an error
+func (r *Report) HasErrors() bool {
+	return r.Errors > 0
+}
+
+func (r *Report) add(severity Severity, code string, nodes []int, format string, args ...interface{}) {
+	if severity == SeverityError {
+		r.Errors++
+	} else {
+		r.Warnings++
+	}
+	r.Issues = append(r.Issues, Issue{
+		Severity: severity,
+		Code:     code,
+		Message:  fmt.Sprintf(format, args...),
+		Nodes:    nodes,
+	})
+}
+
+// counter limits number of issues of one kind
+type counter struct {
+	report   *Report
+	severity Severity
+	code     string
+	count    int
+}
+
+func (c *counter) add(nodes []int, format string, args ...interface{}) {
+	c.count++
+	if c.count <= maxIssues {
+		c.report.add(c.severity, c.code, nodes, format, args...)
+	}
+}
+
+func (c *counter) summarize() {
+	if c.count > maxIssues {
+		c.report.add(c.severity, c.code, nil, "%d more %s issues", c.count-maxIssues, c.code)
+	}
+}
+
+// Validate checks the instance for problems. Asymmetric matrices are
+// reported unless asymetric is set, as the objectives assume symmetry.
+func Validate(tsp *PDPTW, asymetric bool) Report {
+	r := Report{Instance: tsp.name, Issues: []Issue{}}
+
+	if !validateDimensions(tsp, &r) {
+		return r
+	}
+
+	validateMatrix(tsp, &r, asymetric)
+	validateNodes(tsp, &r)
+	validateReachability(tsp, &r)
+
+	return r
+}
+
+func validateDimensions(tsp *PDPTW, r *Report) bool {
+	n := tsp.numNodes
+
+	if tsp.matrix.size != n {
+		r.add(SeverityError, "dimension", nil, "matrix has %d rows, expected %d",
+			tsp.matrix.size, n)
+	}
+
+	for name, values := range map[string][]int{
+		"readyTime":   tsp.readyTime,
+		"dueDate":     tsp.dueDate,
+		"serviceTime": tsp.serviceTime,
+	} {
+		if len(values) != n {
+			r.add(SeverityError, "dimension", nil, "%s has %d values, expected %d",
+				name, len(values), n)
+		}
+	}
+
+	if tsp.startNode < 0 || tsp.startNode >= n {
+		r.add(SeverityError, "dimension", nil, "start node %d is not in [0, %d)",
+			tsp.startNode, n)
+	}
+
+	if tsp.endNode < -1 || tsp.endNode >= n {
+		r.add(SeverityError, "dimension", nil, "end node %d is not in [0, %d)",
+			tsp.endNode, n)
+	} else if tsp.endNode == tsp.startNode {
+		r.add(SeverityError, "dimension", nil, "end node %d is the start node", tsp.endNode)
+	}
+
+	if tsp.vehicles < 1 {
+		r.add(SeverityError, "fleet", nil, "%d vehicles", tsp.vehicles)
+	}
+
+	for k, v := range tsp.fleet {
+		if v.Start < 0 || v.Start >= n || v.End < -1 || v.End >= n {
+			r.add(SeverityError, "fleet", nil, "vehicle %d depots %d and %d are not in [0, %d)",
+				k, v.Start, v.End, n)
+		} else if v.End == v.Start {
+			r.add(SeverityError, "fleet", nil, "vehicle %d ends at its start node %d", k, v.End)
+		}
+		if v.Due != 0 && v.Ready > v.Due {
+			r.add(SeverityError, "fleet", nil, "vehicle %d has shift start %d after its end %d",
+				k, v.Ready, v.Due)
+		}
+	}
+
+	return !r.HasErrors()
+}
+
+func validateMatrix(tsp *PDPTW, r *Report, asymetric bool) {
+	n := tsp.numNodes
+
+	negative := counter{report: r, severity: SeverityError, code: "negative"}
+	diagonal := counter{report: r, severity: SeverityWarning, code: "diagonal"}
+	asymmetric := counter{report: r, severity: SeverityWarning, code: "asymmetric"}
+	triangle := counter{report: r, severity: SeverityWarning, code: "triangle"}
+
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			value := tsp.travel(i, j)
+
+			if value < 0 {
+				negative.add([]int{i, j}, "arc %d->%d has negative travel time %d", i, j, value)
+			}
+			if i == j && value != 0 {
+				diagonal.add([]int{i}, "arc %d->%d has non-zero travel time %d", i, j, value)
+			}
+			if !asymetric && i < j && value != tsp.travel(j, i) {
+				asymmetric.add([]int{i, j}, "arc %d->%d is %d but %d->%d is %d",
+					i, j, value, j, i, tsp.travel(j, i))
+			}
+		}
+	}
+
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			if i == j {
+				continue
+			}
+			for k := 0; k < n; k++ {
+				if k == i || k == j {
+					continue
+				}
+				if tsp.travel(i, k) > tsp.travel(i, j)+tsp.travel(j, k) {
+					triangle.add([]int{i, j, k}, "arc %d->%d (%d) is longer than %d->%d->%d (%d)",
+						i, k, tsp.travel(i, k), i, j, k, tsp.travel(i, j)+tsp.travel(j, k))
+				}
+			}
+		}
+	}
+
+	negative.summarize()
+	diagonal.summarize()
+	asymmetric.summarize()
+	triangle.summarize()
+}
+
+func validateNodes(tsp *PDPTW, r *Report) {
+	window := counter{report: r, severity: SeverityError, code: "window"}
+	negative := counter{report: r, severity: SeverityError, code: "negative"}
+	capacity := counter{report: r, severity: SeverityError, code: "capacity"}
+	pickup := counter{report: r, severity: SeverityError, code: "pickup-demand"}
+	delivery := counter{report: r, severity: SeverityError, code: "delivery-demand"}
+
+	for k := 0; k < tsp.Vehicles(); k++ {
+		if v := tsp.vehicleAt(k); v.Carrying.exceeds(v.Capacity) {
+			capacity.add(nil, "initial load %v of vehicle %d exceeds capacity %v", v.Carrying, k,
+				v.Capacity)
+		}
+	}
+	maxCapacity := tsp.maxCapacity()
+
+	for node := 0; node < tsp.numNodes; node++ {
+		if tsp.readyTime[node] < 0 || tsp.dueDate[node] < 0 || tsp.serviceTime[node] < 0 {
+			negative.add([]int{node}, "node %d has negative time window or service time", node)
+		}
+
+		if tsp.dueDate[node] != 0 && tsp.readyTime[node] > tsp.dueDate[node] {
+			window.add([]int{node}, "node %d has ready time %d after due date %d",
+				node, tsp.readyTime[node], tsp.dueDate[node])
+		}
+
+		demand := tsp.demands[node]
+		if len(demand) != tsp.Dimensions() && demand != nil {
+			capacity.add([]int{node}, "demand %v of node %d has %d amounts, expected %d",
+				demand, node, len(demand), tsp.Dimensions())
+		} else if demand.exceeds(maxCapacity) || demand.negated().exceeds(maxCapacity) {
+			capacity.add([]int{node}, "demand %v of node %d exceeds capacity %v",
+				demand, node, maxCapacity)
+		}
+	}
+
+	for _, pair := range tsp.pairs() {
+		p, d := pair[0], pair[1]
+
+		if !tsp.demands[p].positive() {
+			pickup.add([]int{p, d}, "pickup %d has non-positive demand %v", p, tsp.demands[p])
+		}
+		if !tsp.demands[d].cancels(tsp.demands[p]) {
+			delivery.add([]int{p, d}, "delivery %d demand %v does not cancel pickup %d demand %v",
+				d, tsp.demands[d], p, tsp.demands[p])
+		}
+	}
+
+	if err := tsp.checkPrecedence(); err != nil {
+		r.add(SeverityError, "precedence", nil, "%v", err)
+	}
+
+	window.summarize()
+	negative.summarize()
+	capacity.summarize()
+	pickup.summarize()
+	delivery.summarize()
+}
+
+// validateReachability reports nodes which cannot be served in time even if
+// visited right after the start node by the fastest vehicle, deliveries right
+// after their pickups
+func validateReachability(tsp *PDPTW, r *Report) {
+	unreachable := counter{report: r, severity: SeverityError, code: "unreachable"}
+
+	// earliest arrival at node visited first
+	first := func(node int) (arrival int) {
+		for k := 0; k < tsp.Vehicles(); k++ {
+			v := tsp.vehicleAt(k)
+			if a := tsp.depart(v.Start, v.Ready) + tsp.travel(v.Start, node); k == 0 || a < arrival {
+				arrival = a
+			}
+		}
+		return
+	}
+
+	// earliest departure from node visited first
+	earliest := func(node int) int {
+		return tsp.depart(node, first(node))
+	}
+
+	late := func(node, arrival int) bool {
+		return tsp.dueDate[node] != 0 && arrival > tsp.dueDate[node]
+	}
+
+	paired := make(map[int]bool)
+
+	for _, pair := range tsp.pairs() {
+		p, d := pair[0], pair[1]
+		paired[p], paired[d] = true, true
+
+		arrival := first(p)
+		if late(p, arrival) {
+			unreachable.add([]int{p, d}, "pickup %d cannot be reached before %d, earliest arrival is %d",
+				p, tsp.dueDate[p], arrival)
+			continue
+		}
+
+		arrival = earliest(p) + tsp.travel(p, d)
+		if late(d, arrival) {
+			unreachable.add([]int{p, d}, "delivery %d cannot be reached before %d, earliest arrival is %d",
+				d, tsp.dueDate[d], arrival)
+			continue
+		}
+
+		if limit := tsp.MaxRideTime(d); limit != 0 && tsp.travel(p, d) > limit {
+			unreachable.add([]int{p, d}, "delivery %d cannot be reached within ride time %d, travel takes %d",
+				d, limit, tsp.travel(p, d))
+		}
+	}
+
+	for node := 0; node < tsp.numNodes; node++ {
+		if tsp.isStart(node) || paired[node] {
+			continue
+		}
+
+		arrival := first(node)
+		if late(node, arrival) {
+			unreachable.add([]int{node}, "node %d cannot be reached before %d, earliest arrival is %d",
+				node, tsp.dueDate[node], arrival)
+		}
+	}
+
+	unreachable.summarize()
+}
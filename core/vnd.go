@@ -4,6 +4,8 @@ type vnd struct {
 	objective     objective
 	local2Opt     local2Opt
 	localShifting localshifting
+	localBlocks   localBlocks
+	localRequests localRequests
 }
 
 func (v vnd) process(x *Solution) {
@@ -12,6 +14,11 @@ func (v vnd) process(x *Solution) {
 	for {
 		v.localShifting.process(x2)
 		v.local2Opt.process(x2)
+		// single nodes hardly move under the loading order
+		if x2.tsp.loading != AnyOrder {
+			v.localBlocks.process(x2)
+		}
+		v.localRequests.process(x2)
 
 		if v.objective.get(x2) < v.objective.get(x) {
 			x = x2
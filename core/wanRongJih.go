@@ -0,0 +1,150 @@
+package core
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+var (
+	wanRongJihFields = []string{"id", "pickup", "delivery", "pickupReady", "pickupDue",
+		"deliveryReady", "deliveryDue", "demand"}
+	wanRongJihReplacer = strings.NewReplacer("(", " ", ")", " ", "[", " ", "]", " ", "-->", " ")
+)
+
+// ParseWanRongJih reads the single vehicle PDPTW benchmark of Wan-rong Jih.
+// The file lists "tasks capacity", locations "id (x y)", the initial depot and
+// tasks "id (pickup --> delivery) [ready due] [ready due] demand". The depot
+// becomes node 0, pickup of the i-th task node 2i-1 and its delivery node 2i,
+// the matrix is computed by metric (RoundedEuclidean if nil).
+func ParseWanRongJih(name string, r io.Reader, metric Metric) (*PDPTW, error) {
+	if metric == nil {
+		metric = RoundedEuclidean{}
+	}
+
+	p := wanRongJihParser{lineParser: lineParser{name: name}, locations: make(map[int]Point)}
+
+	if err := p.scan(r, p.parseLine); err != nil {
+		return nil, err
+	}
+
+	if p.section != wanRongJihTasks {
+		return nil, &ParseError{File: p.name, Field: "depot", Err: ErrUndefinedNode}
+	}
+
+	if len(p.tasks) != p.numTasks {
+		return nil, &ParseError{File: p.name, Field: "tasks", Err: fmt.Errorf(
+			"%w: expected %d tasks, got %d", ErrDimension, p.numTasks, len(p.tasks))}
+	}
+
+	return buildPairInstance(p.name, p.capacity, p.locations[p.depot], 0, 0, p.tasks, metric)
+}
+
+const (
+	wanRongJihHeader = iota
+	wanRongJihLocations
+	wanRongJihTasks
+)
+
+type wanRongJihParser struct {
+	lineParser
+	section   int
+	numTasks  int
+	capacity  int
+	depot     int
+	locations map[int]Point
+	tasks     []benchmarkTask
+}
+
+func (p *wanRongJihParser) parseLine(fields []string) error {
+	fields = strings.Fields(wanRongJihReplacer.Replace(strings.Join(fields, " ")))
+
+	switch {
+	case p.section == wanRongJihHeader:
+		if len(fields) != 2 {
+			return p.errorf("header", fmt.Errorf("%w: expected tasks and capacity", ErrHeader))
+		}
+		elems, err := p.ints(fields, func(i int) string {
+			return []string{"tasks", "capacity"}[i]
+		})
+		if err != nil {
+			return err
+		}
+		p.numTasks, p.capacity = elems[0], elems[1]
+		p.section = wanRongJihLocations
+	case p.section == wanRongJihLocations && len(fields) == 1:
+		depot, err := strconv.Atoi(fields[0])
+		if err != nil {
+			return p.errorf("depot", fmt.Errorf("%w %q", ErrSyntax, fields[0]))
+		}
+		if _, ok := p.locations[depot]; !ok {
+			return p.errorf("depot", fmt.Errorf("%w: location %d", ErrUndefinedNode, depot))
+		}
+		p.depot = depot
+		p.section = wanRongJihTasks
+	case p.section == wanRongJihLocations:
+		return p.parseLocation(fields)
+	default:
+		return p.parseTask(fields)
+	}
+	return nil
+}
+
+func (p *wanRongJihParser) parseLocation(fields []string) error {
+	if len(fields) != 3 {
+		return p.errorf("location", fmt.Errorf("%w: expected \"id (x y)\"", ErrTaskFormat))
+	}
+
+	id, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return p.errorf("id", fmt.Errorf("%w %q", ErrSyntax, fields[0]))
+	}
+	if _, ok := p.locations[id]; ok {
+		return p.errorf("id", fmt.Errorf("%w: %d", ErrDuplicateNode, id))
+	}
+
+	var point Point
+
+	if point.X, err = p.float("x", fields[1]); err != nil {
+		return err
+	}
+	if point.Y, err = p.float("y", fields[2]); err != nil {
+		return err
+	}
+
+	p.locations[id] = point
+	return nil
+}
+
+func (p *wanRongJihParser) parseTask(fields []string) error {
+	if len(fields) != len(wanRongJihFields) {
+		return p.errorf("task", fmt.Errorf("%w: expected %d fields, got %d",
+			ErrTaskFormat, len(wanRongJihFields), len(fields)))
+	}
+
+	elems, err := p.ints(fields, func(i int) string { return wanRongJihFields[i] })
+	if err != nil {
+		return err
+	}
+
+	pickup, ok := p.locations[elems[1]]
+	if !ok {
+		return p.errorf("pickup", fmt.Errorf("%w: location %d", ErrUndefinedNode, elems[1]))
+	}
+	delivery, ok := p.locations[elems[2]]
+	if !ok {
+		return p.errorf("delivery", fmt.Errorf("%w: location %d", ErrUndefinedNode, elems[2]))
+	}
+
+	p.tasks = append(p.tasks, benchmarkTask{
+		pickup:        pickup,
+		delivery:      delivery,
+		demand:        elems[7],
+		pickupReady:   elems[3],
+		pickupDue:     elems[4],
+		deliveryReady: elems[5],
+		deliveryDue:   elems[6],
+	})
+	return nil
+}
@@ -0,0 +1,290 @@
+package core
+
+import (
+	"fmt"
+)
+
+// Window is a time window of a node, the service starts in [Ready, Due]
+type Window struct {
+	Ready int
+	Due   int
+}
+
+// SetWindows sets disjoint time windows of the node ordered by time. The
+// ready time and due date of the node become the start of the first and the
+// end of the last window. A single window only sets them.
+func (tsp *PDPTW) SetWindows(node int, windows []Window) error {
+	if node < 0 || node >= tsp.numNodes {
+		return fmt.Errorf("%w: %d", ErrNodeRange, node)
+	}
+	if err := checkWindows(node, windows); err != nil {
+		return err
+	}
+
+	tsp.setWindows(node, windows)
+	return nil
+}
+
+// checkWindows returns error if the windows of the node are missing, overlap
+// or are not ordered
+func checkWindows(node int, windows []Window) error {
+	if len(windows) == 0 {
+		return fmt.Errorf("%w: node %d has no window", ErrWindow, node)
+	}
+
+	for i, w := range windows {
+		switch {
+		case w.Ready < 0 || w.Ready > w.Due || w.Due == 0 && len(windows) > 1:
+			return fmt.Errorf("%w: node %d has window [%d, %d]", ErrWindow, node, w.Ready, w.Due)
+		case i > 0 && w.Ready <= windows[i-1].Due:
+			return fmt.Errorf("%w: windows [%d, %d] and [%d, %d] of node %d overlap or are not ordered",
+				ErrWindow, windows[i-1].Ready, windows[i-1].Due, w.Ready, w.Due, node)
+		}
+	}
+	return nil
+}
+
+// setWindows sets the checked windows of the node
+func (tsp *PDPTW) setWindows(node int, windows []Window) {
+	tsp.readyTime[node] = windows[0].Ready
+	tsp.dueDate[node] = windows[len(windows)-1].Due
+
+	switch {
+	case len(windows) > 1:
+		if tsp.windows == nil {
+			tsp.windows = make([][]Window, tsp.numNodes)
+		}
+		tsp.windows[node] = windows
+	case tsp.windows != nil:
+		tsp.windows[node] = nil
+	}
+}
+
+// Windows returns time windows of the node
+func (tsp *PDPTW) Windows(node int) []Window {
+	if tsp.windows != nil && tsp.windows[node] != nil {
+		return tsp.windows[node]
+	}
+	return []Window{{Ready: tsp.readyTime[node], Due: tsp.dueDate[node]}}
+}
+
+// hasWindows returns whether the node has more than one window
+func (tsp *PDPTW) hasWindows(node int) bool {
+	return tsp.windows != nil && tsp.windows[node] != nil
+}
+
+// start returns start of the service at node reached at arrival, the vehicle
+// waits to the ready time or to the next window. Arrival after the last
+// window is returned as it is.
+func (tsp *PDPTW) start(node, arrival int) int {
+	if !tsp.waits(node) {
+		return arrival
+	}
+	if tsp.hasWindows(node) {
+		for _, w := range tsp.windows[node] {
+			if arrival <= w.Due {
+				if arrival < w.Ready {
+					return w.Ready
+				}
+				return arrival
+			}
+		}
+		return arrival
+	}
+
+	if arrival < tsp.readyTime[node] {
+		return tsp.readyTime[node]
+	}
+	return arrival
+}
+
+// latestStart returns the latest start of the service at node not after t,
+// t itself if it precedes all windows
+func (tsp *PDPTW) latestStart(node, t int) int {
+	if !tsp.hasWindows(node) || !tsp.waits(node) {
+		return t
+	}
+
+	windows := tsp.windows[node]
+	for i := len(windows) - 1; i >= 0; i-- {
+		if windows[i].Ready <= t {
+			if windows[i].Due < t {
+				return windows[i].Due
+			}
+			return t
+		}
+	}
+	return t
+}
+
+// clipWindows drops the windows of the node outside of its ready time and
+// due date and moves the ready time and due date into the remaining ones
+func (tsp *PDPTW) clipWindows(node int) {
+	if !tsp.hasWindows(node) {
+		return
+	}
+
+	due := tsp.due(node)
+
+	var windows []Window
+	for _, w := range tsp.windows[node] {
+		if w.Due < tsp.readyTime[node] || w.Ready > due {
+			continue
+		}
+		if w.Ready < tsp.readyTime[node] {
+			w.Ready = tsp.readyTime[node]
+		}
+		if w.Due > due {
+			w.Due = due
+		}
+		windows = append(windows, w)
+	}
+
+	if len(windows) == 0 {
+		// no window left, ready time stays after the due date
+		tsp.windows[node] = nil
+		return
+	}
+
+	tsp.readyTime[node] = windows[0].Ready
+	if tsp.dueDate[node] != 0 {
+		tsp.dueDate[node] = windows[len(windows)-1].Due
+	}
+	if len(windows) == 1 {
+		windows = nil
+	}
+	tsp.windows[node] = windows
+}
+
+// SoftWindow prices the service outside of the windows of a node. Earliness
+// and Lateness are costs per time unit before the ready time and after the
+// due date, Steps raise the lateness cost of longer delays. Without earliness
+// cost the vehicle waits for the window as if it was hard.
+type SoftWindow struct {
+	Earliness int
+	Lateness  int
+	Steps     []LatenessStep
+}
+
+// LatenessStep is the lateness cost per time unit of the delay beyond After
+type LatenessStep struct {
+	After    int
+	Lateness int
+}
+
+// lateness returns cost of the service delayed after the due date
+func (c SoftWindow) lateness(delay int) (cost int) {
+	rate, from := c.Lateness, 0
+	for _, step := range c.Steps {
+		if delay <= step.After {
+			break
+		}
+		cost += rate * (step.After - from)
+		rate, from = step.Lateness, step.After
+	}
+	return cost + rate*(delay-from)
+}
+
+// softWindows keeps the windows of an instance solved with soft windows
+type softWindows struct {
+	windows [][]Window
+	costs   []SoftWindow
+}
+
+// SetSoftWindow sets costs of the service of the node outside of its windows
+// used when the windows are soft instead of the defaults
+func (tsp *PDPTW) SetSoftWindow(node int, costs SoftWindow) error {
+	if node < 0 || node >= tsp.numNodes {
+		return fmt.Errorf("%w: %d", ErrNodeRange, node)
+	}
+	if costs.Earliness < 0 || costs.Lateness < 0 {
+		return fmt.Errorf("%w: node %d has negative cost", ErrWindow, node)
+	}
+	for i, step := range costs.Steps {
+		if step.After <= 0 || step.Lateness < 0 || i > 0 && step.After <= costs.Steps[i-1].After {
+			return fmt.Errorf("%w: lateness steps of node %d are not increasing", ErrWindow, node)
+		}
+	}
+
+	if tsp.softCosts == nil {
+		tsp.softCosts = make(map[int]SoftWindow)
+	}
+	tsp.softCosts[node] = costs
+	return nil
+}
+
+// SoftWindows returns costs of the nodes set by SetSoftWindow
+func (tsp *PDPTW) SoftWindows() map[int]SoftWindow {
+	return tsp.softCosts
+}
+
+// SoftenWindows turns the time windows into costs, nodes without their own
+// costs are priced by defaults. The due dates no longer bind, ready times and
+// gaps between the windows bind only nodes without earliness cost. The
+// windows themselves are kept, softening again replaces the defaults.
+func (tsp *PDPTW) SoftenWindows(defaults SoftWindow) {
+	soft := &softWindows{
+		windows: make([][]Window, tsp.numNodes),
+		costs:   make([]SoftWindow, tsp.numNodes),
+	}
+
+	for node := 0; node < tsp.numNodes; node++ {
+		soft.windows[node] = tsp.Windows(node)
+		soft.costs[node] = defaults
+		if costs, ok := tsp.softCosts[node]; ok {
+			soft.costs[node] = costs
+		}
+	}
+	tsp.soft = soft
+}
+
+// HardenWindows turns the soft windows back into hard ones
+func (tsp *PDPTW) HardenWindows() {
+	tsp.soft = nil
+}
+
+// isSoft returns whether the windows of the node are soft, start nodes keep
+// hard windows
+func (tsp *PDPTW) isSoft(node int) bool {
+	return tsp.soft != nil && !tsp.isStart(node)
+}
+
+// waits returns whether the vehicle waits for the windows of the node, it
+// does not for soft windows with earliness cost
+func (tsp *PDPTW) waits(node int) bool {
+	return !tsp.isSoft(node) || tsp.soft.costs[node].Earliness == 0
+}
+
+// ready returns the earliest start of the service at node, 0 if the vehicle
+// does not wait for it
+func (tsp *PDPTW) ready(node int) int {
+	if !tsp.waits(node) {
+		return 0
+	}
+	return tsp.readyTime[node]
+}
+
+// windowCost returns cost of the service at node starting at start outside
+// of its soft windows, zero if the windows are hard
+func (tsp *PDPTW) windowCost(node, start int) int {
+	if tsp.soft == nil || tsp.isStart(node) {
+		return 0
+	}
+
+	costs := &tsp.soft.costs[node]
+	cost := -1
+	for _, w := range tsp.soft.windows[node] {
+		if start < w.Ready {
+			if early := costs.Earliness * (w.Ready - start); cost < 0 || early < cost {
+				cost = early
+			}
+			break
+		}
+		if w.Due == 0 || start <= w.Due {
+			return 0
+		}
+		// the last window before the start is the closest one
+		cost = costs.lateness(start - w.Due)
+	}
+	return cost
+}
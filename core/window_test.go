@@ -0,0 +1,33 @@
+package core
+
+import "testing"
+
+func TestSoftWindows(t *testing.T) {
+	// node 4 is reached at 20 and node 2 at 30
+	tsp := parseTestInstance(t, "windows 2 0 5", "windows 4 0 15", "soft 2 0 2 10 4")
+	s := NewSolution(tsp, []int{0, 1, 3, 4, 2})
+
+	if s.IsFeasible() {
+		t.Fatal("late route is feasible with hard windows")
+	}
+
+	tsp.SoftenWindows(SoftWindow{Lateness: 1})
+	if !s.IsFeasible() {
+		t.Fatal("late route is infeasible with soft windows")
+	}
+	// node 2 is 10 units late for 2 and 15 for 4, node 4 takes the defaults
+	if cost := tsp.windowCost(2, 30); cost != 80 {
+		t.Errorf("node 2 costs %d, want 80", cost)
+	}
+	if cost := tsp.windowCost(4, 20); cost != 5 {
+		t.Errorf("node 4 costs %d, want 5", cost)
+	}
+	if cost := tsp.windowCost(1, 10); cost != 0 {
+		t.Errorf("node 1 in its window costs %d", cost)
+	}
+
+	tsp.HardenWindows()
+	if s.IsFeasible() {
+		t.Fatal("late route is feasible after hardening the windows")
+	}
+}
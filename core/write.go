@@ -0,0 +1,154 @@
+package core
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// Write writes the instance in the .psa format read by ParseInstance.
+// Coordinates are not part of the format, only the matrix is written.
+func (tsp *PDPTW) Write(w io.Writer) error {
+	out := bufio.NewWriter(w)
+
+	// header
+	if tsp.traveled != 0 || !tsp.carrying.isZero() {
+		fmt.Fprintf(out, "%d %s %d %d %s\n", tsp.numNodes, tsp.capacity, tsp.startNode,
+			tsp.traveled, tsp.carrying)
+	} else {
+		fmt.Fprintf(out, "%d %s %d\n", tsp.numNodes, tsp.capacity, tsp.startNode)
+	}
+
+	// matrix
+	buf := make([]byte, 0, 8*tsp.numNodes)
+	for _, row := range tsp.matrix.rows() {
+		buf = buf[:0]
+		for j, value := range row {
+			if j > 0 {
+				buf = append(buf, ' ')
+			}
+			buf = strconv.AppendInt(buf, int64(value), 10)
+		}
+		buf = append(buf, '\n')
+		out.Write(buf)
+	}
+
+	// tasks
+	delivery := make(map[int]int)
+	for _, pair := range tsp.pairs() {
+		delivery[pair[0]] = pair[1]
+	}
+
+	for node := 0; node < tsp.numNodes; node++ {
+		if pickup, ok := tsp.precedence[node]; ok && pickup >= 0 {
+			// written with its pickup
+			continue
+		}
+
+		if d, ok := delivery[node]; ok {
+			if err := tsp.writePair(out, node, d); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if node == tsp.startNode && !tsp.isDefined(node) {
+			continue
+		}
+
+		fmt.Fprintf(out, "%d %s %d %d", node, tsp.demand(node), tsp.readyTime[node],
+			tsp.dueDate[node])
+		if tsp.serviceTime[node] != 0 {
+			fmt.Fprintf(out, " %d", tsp.serviceTime[node])
+		}
+		fmt.Fprintln(out)
+	}
+
+	switch {
+	case tsp.fleet != nil:
+		for _, v := range tsp.fleet {
+			fmt.Fprintf(out, "vehicle %s %d %d %d %d %d", v.Capacity, v.Start, v.End, v.Ready,
+				v.Due, v.Cost)
+			if !v.Carrying.isZero() {
+				fmt.Fprintf(out, " %s", v.Carrying)
+			}
+			fmt.Fprintln(out)
+		}
+	case tsp.vehicles > 1:
+		fmt.Fprintf(out, "vehicles %d\n", tsp.vehicles)
+	}
+
+	if tsp.endNode >= 0 && tsp.fleet == nil {
+		fmt.Fprintf(out, "end %d\n", tsp.endNode)
+	}
+
+	if tsp.loading != AnyOrder {
+		fmt.Fprintf(out, "loading %s\n", tsp.loading)
+	}
+
+	if tsp.maxDuration != 0 {
+		fmt.Fprintf(out, "duration %d\n", tsp.maxDuration)
+	}
+	if b := tsp.rest; b != nil {
+		fmt.Fprintf(out, "break %d %d %d\n", b.Ready, b.Due, b.Duration)
+	}
+
+	for node := 0; node < tsp.numNodes; node++ {
+		if tsp.hasWindows(node) {
+			fmt.Fprintf(out, "windows %d", node)
+			for _, w := range tsp.windows[node] {
+				fmt.Fprintf(out, " %d %d", w.Ready, w.Due)
+			}
+			fmt.Fprintln(out)
+		}
+		if costs, ok := tsp.softCosts[node]; ok {
+			fmt.Fprintf(out, "soft %d %d %d", node, costs.Earliness, costs.Lateness)
+			for _, step := range costs.Steps {
+				fmt.Fprintf(out, " %d %d", step.After, step.Lateness)
+			}
+			fmt.Fprintln(out)
+		}
+	}
+
+	for _, pair := range tsp.pairs() {
+		if limit := tsp.MaxRideTime(pair[1]); limit != 0 {
+			fmt.Fprintf(out, "ride %d %d %d\n", pair[0], pair[1], limit)
+		}
+	}
+
+	for node := 0; node < tsp.numNodes; node++ {
+		if cost := tsp.RejectionCost(node); cost != 0 {
+			fmt.Fprintf(out, "optional %d %d\n", node, cost)
+		}
+	}
+
+	for _, rule := range tsp.precedences(false) {
+		fmt.Fprintf(out, "precedence %d %d\n", rule[0], rule[1])
+	}
+
+	return out.Flush()
+}
+
+func (tsp *PDPTW) writePair(out io.Writer, pickup, delivery int) error {
+	if !tsp.demands[delivery].cancels(tsp.demands[pickup]) {
+		return fmt.Errorf("%w: delivery demand %v does not cancel pickup demand %v",
+			ErrTaskFormat, tsp.demands[delivery], tsp.demands[pickup])
+	}
+
+	fmt.Fprintf(out, "%d %d %s %d %d %d %d", pickup, delivery, tsp.demand(pickup),
+		tsp.readyTime[pickup], tsp.dueDate[pickup], tsp.readyTime[delivery],
+		tsp.dueDate[delivery])
+	if tsp.serviceTime[pickup] != 0 || tsp.serviceTime[delivery] != 0 {
+		fmt.Fprintf(out, " %d %d", tsp.serviceTime[pickup], tsp.serviceTime[delivery])
+	}
+	fmt.Fprintln(out)
+	return nil
+}
+
+// isDefined returns whether the node has a task of its own
+func (tsp *PDPTW) isDefined(node int) bool {
+	_, ok := tsp.precedence[node]
+	return ok || !tsp.demands[node].isZero() || tsp.readyTime[node] != 0 ||
+		tsp.dueDate[node] != 0 || tsp.serviceTime[node] != 0
+}
@@ -0,0 +1,41 @@
+package core
+
+import (
+	"strings"
+	"testing"
+)
+
+// testDirectives are the directives of the test instance in the order they
+// are written
+var testDirectives = []string{
+	"vehicles 2",
+	"loading lifo",
+	"duration 90",
+	"break 20 60 5",
+	"windows 2 0 30 50 100",
+	"soft 3 1 2 10 4",
+	"ride 1 2 40",
+	"optional 3 50",
+}
+
+// parseTestInstance returns the test instance followed by the lines
+func parseTestInstance(t *testing.T, lines ...string) *PDPTW {
+	text := testPSA + strings.Join(lines, "\n")
+	tsp, err := ParseNamedInstance("test.psa", strings.NewReader(text))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tsp
+}
+
+func TestWriteRoundTrip(t *testing.T) {
+	tsp := parseTestInstance(t, testDirectives...)
+
+	var b strings.Builder
+	if err := tsp.Write(&b); err != nil {
+		t.Fatal(err)
+	}
+	if want := testPSA + strings.Join(testDirectives, "\n") + "\n"; b.String() != want {
+		t.Fatalf("written\n%s\nwant\n%s", b.String(), want)
+	}
+}
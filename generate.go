@@ -0,0 +1,56 @@
+package main
+
+import (
+	"os"
+	"path"
+
+	"github.com/mitas1/psa-core/core"
+	"github.com/spf13/pflag"
+)
+
+// generate writes random instances into the instances directory
+func generate(args []string) {
+	flags := pflag.NewFlagSet("generate", pflag.ExitOnError)
+
+	opts := core.GeneratorOptions{}
+
+	flags.Int64Var(&opts.Seed, "seed", 1, "Seed of the first instance.")
+	flags.IntVar(&opts.Pairs, "pairs", 50, "Number of pickup and delivery pairs.")
+	flags.Float64Var(&opts.CapacityTightness, "capacity-tightness", 0.5,
+		"Capacity tightness in [0, 1], 1 is the tightest capacity.")
+	flags.IntVar(&opts.WindowWidth, "window", 100, "Width of time windows.")
+	flags.IntVar(&opts.Horizon, "horizon", 1000, "Planning horizon.")
+	flags.IntVar(&opts.Clusters, "clusters", 0, "Number of clusters of locations, 0 is uniform.")
+	flags.Float64Var(&opts.Asymmetry, "asymmetry", 0,
+		"Maximal relative increase of an arc in [0, 1].")
+	flags.IntVar(&opts.MaxDemand, "max-demand", 10, "Maximal demand of a pair.")
+	count := flags.IntP("count", "n", 1, "Number of instances, seeds increase by one.")
+	output := flags.StringP("output", "o", "_instances/generated", "Path to instances dir.")
+	flags.Parse(args)
+
+	if err := os.MkdirAll(*output, os.ModePerm); err != nil {
+		log.Fatal(err)
+	}
+
+	for i := 0; i < *count; i++ {
+		tsp, err := core.GenerateInstance(opts)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		name := path.Join(*output, tsp.Name()+".psa")
+
+		file, err := os.Create(name)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		if err := tsp.Write(file); err != nil {
+			log.Fatal(err)
+		}
+		file.Close()
+
+		log.Infof("Instance generated: %v", name)
+		opts.Seed++
+	}
+}
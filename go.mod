@@ -1,6 +1,6 @@
 module github.com/mitas1/psa-core
 
-go 1.12
+go 1.13
 
 require (
 	github.com/op/go-logging v0.0.0-20160315200505-970db520ece7
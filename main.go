@@ -1,7 +1,9 @@
 package main
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"path"
@@ -21,7 +23,8 @@ const (
 	SOLUTION_PATH = "_solutions"
 )
 
-func parseFlags() (config, logFile, instanceName, instancePath *string, iterations *int) {
+func parseFlags() (config, logFile, instanceName, instancePath, format, metric *string,
+	speed *float64, iterations *int, save *bool) {
 	config = pflag.StringP(
 		"config",
 		"c",
@@ -46,18 +49,88 @@ func parseFlags() (config, logFile, instanceName, instancePath *string, iteratio
 		"_instances/wan-rong-jih",
 		"Path to instances dir.",
 	)
+	format = pflag.StringP(
+		"format",
+		"F",
+		string(core.PSAFormat),
+		"Format of instance files (psa, lilim, json, hosny, wan-rong-jih, tsptw, tsptw-coords).",
+	)
+	metric = pflag.StringP(
+		"metric",
+		"m",
+		core.RoundedEuclideanMetric,
+		"Distance metric of instances with coordinates (euclidean, euc2d, ceil2d, manhattan, haversine).",
+	)
+	speed = pflag.Float64(
+		"speed",
+		1,
+		"Speed in kilometers per time unit used by the haversine metric.",
+	)
 	iterations = pflag.IntP(
 		"iterations",
 		"t",
 		1,
 		"Number of iterations.",
 	)
+	save = pflag.BoolP(
+		"save",
+		"s",
+		false,
+		"Save the best solution of every instance as JSON into "+SOLUTION_PATH+".",
+	)
 	pflag.Parse()
 	return
 }
 
 type solver struct {
-	core *core.Core
+	core   *core.Core
+	format core.Format
+	metric core.Metric
+	save   bool
+}
+
+// result is the route of a single vehicle or the plan of several vehicles
+type result interface {
+	MakeSpan() int
+	Check() bool
+	WriteJSON(w io.Writer) error
+}
+
+// solve returns the route of a single vehicle or the plan of several
+// vehicles, nil if none is found
+func (s solver) solve(pdptw *core.PDPTW) (result, error) {
+	if pdptw.Vehicles() > 1 {
+		plan, err := s.core.ProcessPlan(pdptw)
+		if plan == nil {
+			return nil, err
+		}
+		return plan, err
+	}
+
+	solution, err := s.core.Process(pdptw)
+	if solution == nil {
+		return nil, err
+	}
+	return solution, err
+}
+
+// saveSolution writes the solution into SOLUTION_PATH, returns error if there
+// is no solution
+func saveSolution(sol result, name string) error {
+	if sol == nil {
+		return errors.New("no solution to save")
+	}
+
+	os.MkdirAll(SOLUTION_PATH, os.ModePerm)
+
+	file, err := os.Create(path.Join(SOLUTION_PATH, path.Base(name)+".json"))
+	if err != nil {
+		return err
+	}
+
+	defer file.Close()
+
+	return sol.WriteJSON(file)
 }
 
 func (s solver) solveInstance(_path, name string, maxIter int) (latexOut string) {
@@ -67,6 +140,8 @@ func (s solver) solveInstance(_path, name string, maxIter int) (latexOut string)
 		log.Fatal(err)
 	}
 
+	defer file.Close()
+
 	fileInfo, err := file.Stat()
 	if err != nil {
 		log.Fatal(err)
@@ -77,19 +152,28 @@ func (s solver) solveInstance(_path, name string, maxIter int) (latexOut string)
 		return
 	}
 
-	pdptw := core.ReadFromFile(_path, name)
+	pdptw, err := core.ParseFormat(s.format, name, file, s.metric)
+	if err != nil {
+		log.Errorf("Skipping instance: %v", err)
+		return
+	}
 
 	var totalDuration float64
 	var totalObjective int
+	var best result
 
 	for iteration := 1; iteration < maxIter+1; iteration++ {
 		log.Infof("Solving instance: %v, iteration: %d", name, iteration)
 		start := time.Now()
-		sol, err := s.core.Process(pdptw)
+		sol, err := s.solve(pdptw)
 		if err != nil {
 			log.Error(err)
 			return
 		}
+		if sol == nil {
+			log.Errorf("No solution of instance %v found", name)
+			return
+		}
 		duration := time.Since(start)
 		log.Infof(`Instance solved!
 		Name:			%v
@@ -101,16 +185,48 @@ func (s solver) solveInstance(_path, name string, maxIter int) (latexOut string)
 
 		totalObjective += sol.MakeSpan()
 		totalDuration += duration.Seconds()
+
+		if best == nil || sol.MakeSpan() < best.MakeSpan() {
+			best = sol
+		}
+	}
+
+	if s.save {
+		if err := saveSolution(best, name); err != nil {
+			log.Errorf("Saving solution of %v: %v", name, err)
+		}
+	}
+
+	if maxIter < 1 {
+		log.Errorf("Instance %v not solved in %d iterations", name, maxIter)
+		return
 	}
 
 	latexOut = fmt.Sprintf("%v	&	%v	&	%.4f\n", pdptw.NumberOfTasks(),
-		totalObjective / maxIter, totalDuration / float64(maxIter))
+		totalObjective/maxIter, totalDuration/float64(maxIter))
 
 	return
 }
 
 func main() {
-	config, file, instanceName, instancesPath, iterations := parseFlags()
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "generate":
+			log = logging.SetupLogger(nil)
+			generate(os.Args[2:])
+			return
+		case "convert":
+			log = logging.SetupLogger(nil)
+			convert(os.Args[2:])
+			return
+		case "validate":
+			log = logging.SetupLogger(nil)
+			validate(os.Args[2:])
+			return
+		}
+	}
+
+	config, file, instanceName, instancesPath, format, metricName, speed, iterations, save := parseFlags()
 
 	log = logging.SetupLogger(file)
 
@@ -124,7 +240,13 @@ func main() {
 
 	var latex string
 
-	solver := solver{core: core.NewCore(&c)}
+	metric, err := core.NewMetric(*metricName, *speed)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	solver := solver{core: core.NewCore(&c), format: core.Format(*format), metric: metric,
+		save: *save}
 
 	if instanceName != nil && *instanceName != "" {
 		latex += solver.solveInstance("", *instanceName, *iterations)
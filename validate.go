@@ -0,0 +1,95 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path"
+
+	_config "github.com/mitas1/psa-core/config"
+	"github.com/mitas1/psa-core/core"
+	"github.com/spf13/pflag"
+)
+
+// validate prints JSON reports of the given instances and exits with status 1
+// if any of them has errors
+func validate(args []string) {
+	flags := pflag.NewFlagSet("validate", pflag.ExitOnError)
+
+	config := flags.StringP("config", "c", "config.yaml", "Path to a config file.")
+	instancesPath := flags.StringP("instances-path", "p", "",
+		"Path to instances dir, used if no instance file is given.")
+	format := flags.StringP("format", "F", string(core.PSAFormat),
+		"Format of instance files (psa, lilim, json, hosny, wan-rong-jih, tsptw, tsptw-coords).")
+	metricName := flags.StringP("metric", "m", core.RoundedEuclideanMetric,
+		"Distance metric of instances with coordinates.")
+	speed := flags.Float64("speed", 1, "Speed in kilometers per time unit used by the haversine metric.")
+	flags.Parse(args)
+
+	c := _config.Config{}
+	if err := c.LoadConfig(*config); err != nil {
+		log.Fatal(err)
+	}
+
+	metric, err := core.NewMetric(*metricName, *speed)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	files := flags.Args()
+
+	if len(files) == 0 && *instancesPath != "" {
+		infos, err := ioutil.ReadDir(*instancesPath)
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, info := range infos {
+			if !info.IsDir() {
+				files = append(files, path.Join(*instancesPath, info.Name()))
+			}
+		}
+	}
+
+	reports := []core.Report{}
+	failed := false
+
+	for _, name := range files {
+		var report core.Report
+
+		tsp, err := readInstance(name, core.Format(*format), metric)
+		if err != nil {
+			report = core.Report{Instance: name, Errors: 1, Issues: []core.Issue{{
+				Severity: core.SeverityError,
+				Code:     "parse",
+				Message:  err.Error(),
+			}}}
+		} else {
+			report = core.Validate(tsp, c.Optimization.Asymetric)
+		}
+
+		failed = failed || report.HasErrors()
+		reports = append(reports, report)
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(reports); err != nil {
+		log.Fatal(err)
+	}
+
+	if failed {
+		os.Exit(1)
+	}
+}
+
+func readInstance(name string, format core.Format, metric core.Metric) (*core.PDPTW, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+
+	defer file.Close()
+
+	return core.ParseFormat(format, name, file, metric)
+}